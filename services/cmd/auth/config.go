@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 	"github.com/pkg/errors"
 )
@@ -13,12 +15,32 @@ func parseEnv() (*config, error) {
 	if c.DBHost == "" || c.DBPort == "" || c.DBName == "" || c.DBUser == "" || c.DBPassword == "" {
 		return c, errors.New("db env params not set")
 	}
+	if c.SessionPurgeInterval <= 0 {
+		return c, errors.Errorf("session purge interval should be positive, got %s", c.SessionPurgeInterval)
+	}
 	return c, nil
 }
 
 type config struct {
 	ServicePort string `envconfig:"service_port" default:"8000"`
-	JWTSecret   string `envconfig:"jwt_secret" default:"secret"`
+	// JWTSigningKey is PEM encoded RSA or Ed25519 private key, random key is generated when empty
+	JWTSigningKey string `envconfig:"jwt_signing_key"`
+	// JWTRetiredKeys are PEM encoded previous signing keys, they are published until tokens signed with them expire
+	JWTRetiredKeys string `envconfig:"jwt_retired_keys"`
+	// PasswordHashCost is bcrypt cost, passwords hashed with another cost are rehashed on login
+	PasswordHashCost int `envconfig:"password_hash_cost" default:"10"`
+
+	// SessionIdleLifetime is the cookie session sliding window, each request moves it
+	SessionIdleLifetime time.Duration `envconfig:"session_idle_lifetime" default:"30m"`
+	// SessionMaxLifetime is the absolute cookie session lifetime since login
+	SessionMaxLifetime  time.Duration `envconfig:"session_max_lifetime" default:"24h"`
+	AccessTokenLifetime time.Duration `envconfig:"access_token_lifetime" default:"15m"`
+	// RefreshTokenLifetime is the token session sliding window, each refresh moves it
+	RefreshTokenLifetime time.Duration `envconfig:"refresh_token_lifetime" default:"168h"`
+	// TokenSessionMaxLifetime is the absolute token session lifetime since the password grant
+	TokenSessionMaxLifetime time.Duration `envconfig:"token_session_max_lifetime" default:"720h"`
+	// SessionPurgeInterval is the period of removing expired sessions, it should be positive
+	SessionPurgeInterval time.Duration `envconfig:"session_purge_interval" default:"1m"`
 
 	DBHost     string `envconfig:"db_host" default:"localhost"`
 	DBPort     string `envconfig:"db_port" default:"5433"`
@@ -92,6 +92,42 @@ func initRabbitMQEnv(cfg *config) (streams.Environment, error) {
 		})
 }
 
+func initSigningKeys(cfg *config, logger *logrus.Logger) (jwtauth.SigningKey, jwtauth.JWKS, error) {
+	var signingKey jwtauth.SigningKey
+	if cfg.JWTSigningKey == "" {
+		logger.Warn("jwt signing key not set, tokens are signed with random key valid until restart")
+		key, err := jwtauth.GenerateSigningKey()
+		if err != nil {
+			return signingKey, jwtauth.JWKS{}, err
+		}
+		signingKey = key
+	} else {
+		keys, err := jwtauth.ParseSigningKeys([]byte(cfg.JWTSigningKey))
+		if err != nil {
+			return signingKey, jwtauth.JWKS{}, errors.Wrap(err, "failed to parse jwt signing key")
+		}
+		if len(keys) > 1 {
+			return signingKey, jwtauth.JWKS{}, errors.New("jwt signing key must contain single key")
+		}
+		signingKey = keys[0]
+	}
+
+	publishedKeys := []jwtauth.SigningKey{signingKey}
+	if cfg.JWTRetiredKeys != "" {
+		retiredKeys, err := jwtauth.ParseSigningKeys([]byte(cfg.JWTRetiredKeys))
+		if err != nil {
+			return signingKey, jwtauth.JWKS{}, errors.Wrap(err, "failed to parse jwt retired keys")
+		}
+		publishedKeys = append(publishedKeys, retiredKeys...)
+	}
+	jwks, err := jwtauth.NewJWKS(publishedKeys...)
+	if err != nil {
+		return signingKey, jwtauth.JWKS{}, err
+	}
+	logger.WithField("kid", signingKey.ID).Info("jwt signing key loaded")
+	return signingKey, jwks, nil
+}
+
 func waitForKillSignal(logger *logrus.Logger) {
 	sysKillSignal := make(chan os.Signal, 1)
 	signal.Notify(sysKillSignal, os.Interrupt, syscall.SIGTERM)
@@ -115,14 +151,38 @@ func startServer(
 	if err != nil {
 		logger.Fatal(err)
 	}
-	userService := app.NewUserService(dbDep, eventStore, encoding.NewPasswordEncoder())
-	sessionRepo := postgres.NewSessionRepository(connector.Client())
-	tokenGenerator := jwtauth.NewTokenGenerator(cfg.JWTSecret)
-	userServer := serverhttp.NewServer(userService, sessionRepo, tokenGenerator, logger)
+	passwordEncoder, err := encoding.NewPasswordEncoder(cfg.PasswordHashCost)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	userService := app.NewUserService(dbDep, eventStore, passwordEncoder)
+	tokenService := app.NewTokenService(dbDep, app.TokenLifetime{
+		Access: cfg.AccessTokenLifetime,
+		Session: app.SessionLifetime{
+			Idle: cfg.RefreshTokenLifetime,
+			Max:  cfg.TokenSessionMaxLifetime,
+		},
+	})
+	sessionService := app.NewSessionService(postgres.NewSessionRepository(connector.Client()), app.SessionLifetime{
+		Idle: cfg.SessionIdleLifetime,
+		Max:  cfg.SessionMaxLifetime,
+	})
+	startSessionPurge(ctx, sessionService, cfg.SessionPurgeInterval, logger)
+	signingKey, jwks, err := initSigningKeys(cfg, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	jwksHandler, err := serverhttp.MakeJWKSHandler(jwks)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	tokenGenerator := jwtauth.NewTokenGenerator(signingKey)
+	userServer := serverhttp.NewServer(userService, tokenService, sessionService, tokenGenerator, logger)
 
 	router := mux.NewRouter()
 	router.HandleFunc("/health", handleHealth).Methods(http.MethodGet)
 	router.HandleFunc("/ready", handleReady(connector)).Methods(http.MethodGet)
+	router.HandleFunc(serverhttp.JWKSEndpoint, jwksHandler).Methods(http.MethodGet)
 	router.PathPrefix(serverhttp.PathPrefix).Handler(userServer.MakeHandler())
 
 	metricsHandler.AddMetricsHandler(router, "/metrics")
@@ -142,6 +202,34 @@ func startServer(
 	return server
 }
 
+// startSessionPurge periodically removes expired sessions, so the session table does not grow with abandoned ones
+func startSessionPurge(ctx context.Context, sessionService *app.SessionService, interval time.Duration, logger *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			case <-ticker.C:
+				for {
+					removed, err := sessionService.PurgeExpired(time.Now())
+					if err != nil {
+						logger.Error(err)
+						break
+					}
+					if removed > 0 {
+						logger.Infof("removed %d expired sessions", removed)
+					}
+					if removed < app.PurgeBatchSize {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
+
 func handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 	"github.com/pkg/errors"
 )
@@ -21,7 +23,14 @@ func parseEnv() (*config, error) {
 
 type config struct {
 	ServicePort string `envconfig:"service_port" default:"8000"`
-	JWTSecret   string `envconfig:"jwt_secret" default:"secret"`
+	// JWKSURL is where the auth service publishes keys tokens are verified with
+	JWKSURL string `envconfig:"jwks_url" default:"http://localhost:8000/.well-known/jwks.json"`
+
+	AccountCurrency string `envconfig:"account_currency" default:"USD"`
+
+	// HoldLifetime is time for authorized payment to be captured before the held amount is released
+	HoldLifetime           time.Duration `envconfig:"hold_lifetime" default:"24h"`
+	HoldExpirationInterval time.Duration `envconfig:"hold_expiration_interval" default:"1m"`
 
 	DBHost     string `envconfig:"db_host" default:"localhost"`
 	DBPort     string `envconfig:"db_port" default:"5433"`
@@ -5,10 +5,13 @@ import (
 	"arch-homework/pkg/billing/infrastructure/integrationevent"
 	"arch-homework/pkg/billing/infrastructure/postgres"
 	serverhttp "arch-homework/pkg/billing/infrastructure/transport/http"
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/streams"
+	"arch-homework/pkg/common/infrastructure/idempotency"
 	commonintegrationevent "arch-homework/pkg/common/infrastructure/integrationevent"
 	"arch-homework/pkg/common/infrastructure/metrics"
 	commonpostgres "arch-homework/pkg/common/infrastructure/postgres"
+	"arch-homework/pkg/common/infrastructure/storedevent"
 	infrastreams "arch-homework/pkg/common/infrastructure/streams"
 	"arch-homework/pkg/common/jwtauth"
 
@@ -53,12 +56,14 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	metricsHandler, err := metrics.NewPrometheusMetricsHandler(metrics.NewDefaultEndpointLabelCollector())
+	metricsHandler, err := metrics.NewPrometheusMetricsHandler(serverhttp.NewEndpointLabelCollector())
 	if err != nil {
 		logger.Fatal(err)
 	}
 
-	server := startServer(cfg, connector, rmqEnv, logger, metricsHandler)
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	server := startServer(ctx, cfg, connector, rmqEnv, logger, metricsHandler)
 
 	waitForKillSignal(logger)
 	if err := server.Shutdown(context.Background()); err != nil {
@@ -98,6 +103,7 @@ func waitForKillSignal(logger *logrus.Logger) {
 }
 
 func startServer(
+	ctx context.Context,
 	cfg *config,
 	connector commonpostgres.Connector,
 	rmqEnv streams.Environment,
@@ -109,24 +115,44 @@ func startServer(
 		logger.Fatal(err)
 	}
 
+	accountCurrency, err := money.ParseCurrency(cfg.AccountCurrency)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
 	trUnitFactory := postgres.NewTransactionalUnitFactory(connector.Client())
-	eventHandler := app.NewEventHandler(trUnitFactory, integrationevent.NewEventParser())
+	eventSender, err := storedevent.NewEventSender(ctx, postgres.NewEventStore(connector.Client()), rmqEnv, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	eventHandler := app.NewEventHandler(trUnitFactory, eventSender, integrationevent.NewEventParser(), accountCurrency, cfg.HoldLifetime)
 
 	if err := commonintegrationevent.StartEventConsumer(rmqEnv, eventHandler, logger); err != nil {
 		logger.Fatal(err)
 	}
 
-	tokenParser := jwtauth.NewTokenParser(cfg.JWTSecret)
-	_ = tokenParser
+	tokenParser := jwtauth.NewTokenParser(cfg.JWKSURL)
 
-	billingQueryService := app.NewBillingQueryService(postgres.NewUserAccountRepository(connector.Client()))
-	billingService := app.NewBillingService(trUnitFactory)
+	billingService := app.NewBillingService(trUnitFactory, eventSender, accountCurrency, cfg.HoldLifetime)
+	startHoldExpiration(ctx, billingService, cfg.HoldExpirationInterval, logger)
+
+	billingQueryService := app.NewBillingQueryService(
+		postgres.NewUserAccountRepository(connector.Client()),
+		postgres.NewLedgerRepositoryRead(connector.Client()),
+	)
 	billingServer := serverhttp.NewServer(billingService, billingQueryService, tokenParser, logger)
 
+	idempotencyMiddleware := idempotency.NewMiddleware(
+		ctx,
+		idempotency.NewRepository(connector.Client()),
+		idempotency.NewTokenUserIDExtractor(tokenParser),
+		logger,
+	)
+
 	router := mux.NewRouter()
 	router.HandleFunc("/health", handleHealth).Methods(http.MethodGet)
 	router.HandleFunc("/ready", handleReady(connector)).Methods(http.MethodGet)
-	router.PathPrefix(serverhttp.PathPrefix).Handler(billingServer.MakeHandler())
+	router.PathPrefix(serverhttp.PathPrefix).Handler(idempotencyMiddleware(billingServer.MakeHandler()))
 	router.PathPrefix(serverhttp.PathPrefixInternal).Handler(billingServer.MakeInternalHandler())
 
 	metricsHandler.AddMetricsHandler(router, "/metrics")
@@ -146,6 +172,34 @@ func startServer(
 	return server
 }
 
+// startHoldExpiration periodically releases holds of payments which have not been captured in time
+func startHoldExpiration(ctx context.Context, billingService app.BillingService, interval time.Duration, logger *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			case <-ticker.C:
+				for {
+					expired, err := billingService.ExpireAuthorizations(time.Now())
+					if err != nil {
+						logger.Error(err)
+						break
+					}
+					if expired > 0 {
+						logger.Infof("expired %d payment authorizations", expired)
+					}
+					if expired < app.ExpirationBatchSize {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
+
 func handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
@@ -5,6 +5,7 @@ import (
 	commonintegrationevent "arch-homework/pkg/common/infrastructure/integrationevent"
 	"arch-homework/pkg/common/infrastructure/metrics"
 	commonpostgres "arch-homework/pkg/common/infrastructure/postgres"
+	"arch-homework/pkg/common/infrastructure/storedevent"
 	infrastreams "arch-homework/pkg/common/infrastructure/streams"
 	"arch-homework/pkg/common/jwtauth"
 	"arch-homework/pkg/notification/app"
@@ -58,7 +59,9 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	server := startServer(cfg, connector, rmqEnv, logger, metricsHandler)
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	server := startServer(ctx, cfg, connector, rmqEnv, logger, metricsHandler)
 
 	waitForKillSignal(logger)
 	if err := server.Shutdown(context.Background()); err != nil {
@@ -98,6 +101,7 @@ func waitForKillSignal(logger *logrus.Logger) {
 }
 
 func startServer(
+	ctx context.Context,
 	cfg *config,
 	connector commonpostgres.Connector,
 	rmqEnv streams.Environment,
@@ -110,13 +114,17 @@ func startServer(
 	}
 
 	trUnitFactory := postgres.NewTransactionalUnitFactory(connector.Client())
-	eventHandler := app.NewEventHandler(trUnitFactory, integrationevent.NewEventParser())
+	eventSender, err := storedevent.NewEventSender(ctx, postgres.NewEventStore(connector.Client()), rmqEnv, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	eventHandler := app.NewEventHandler(trUnitFactory, eventSender, integrationevent.NewEventParser())
 
 	if err := commonintegrationevent.StartEventConsumer(rmqEnv, eventHandler, logger); err != nil {
 		logger.Fatal(err)
 	}
 
-	tokenParser := jwtauth.NewTokenParser(cfg.JWTSecret)
+	tokenParser := jwtauth.NewTokenParser(cfg.JWKSURL)
 	_ = tokenParser
 
 	notificationRepo := postgres.NewNotificationRepository(connector.Client())
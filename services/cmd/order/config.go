@@ -21,9 +21,8 @@ func parseEnv() (*config, error) {
 
 type config struct {
 	ServicePort string `envconfig:"service_port" default:"8000"`
-	JWTSecret   string `envconfig:"jwt_secret" default:"secret"`
-
-	BillingServiceHost string `envconfig:"billing_host" default:"http://billing-app:8000"`
+	// JWKSURL is where the auth service publishes keys tokens are verified with
+	JWKSURL string `envconfig:"jwks_url" default:"http://localhost:8000/.well-known/jwks.json"`
 
 	DBHost     string `envconfig:"db_host" default:"localhost"`
 	DBPort     string `envconfig:"db_port" default:"5433"`
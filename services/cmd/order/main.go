@@ -2,13 +2,15 @@ package main
 
 import (
 	"arch-homework/pkg/common/app/streams"
+	"arch-homework/pkg/common/infrastructure/idempotency"
+	commonintegrationevent "arch-homework/pkg/common/infrastructure/integrationevent"
 	"arch-homework/pkg/common/infrastructure/metrics"
 	commonpostgres "arch-homework/pkg/common/infrastructure/postgres"
 	"arch-homework/pkg/common/infrastructure/storedevent"
 	infrastreams "arch-homework/pkg/common/infrastructure/streams"
 	"arch-homework/pkg/common/jwtauth"
 	"arch-homework/pkg/order/app"
-	"arch-homework/pkg/order/infrastructure/billing"
+	"arch-homework/pkg/order/infrastructure/integrationevent"
 	"arch-homework/pkg/order/infrastructure/postgres"
 	serverhttp "arch-homework/pkg/order/infrastructure/transport/http"
 
@@ -116,15 +118,28 @@ func startServer(
 	if err != nil {
 		logger.Fatal(err)
 	}
-	billingClient := billing.NewClient(http.Client{}, cfg.BillingServiceHost)
-	orderService := app.NewOrderService(dbDep, eventStore, billingClient)
-	tokenParser := jwtauth.NewTokenParser(cfg.JWTSecret)
-	userServer := serverhttp.NewServer(orderService, tokenParser, logger)
+
+	eventHandler := app.NewEventHandler(dbDep, eventStore, integrationevent.NewEventParser())
+	if err := commonintegrationevent.StartEventConsumer(rmqEnv, eventHandler, logger); err != nil {
+		logger.Fatal(err)
+	}
+
+	orderService := app.NewOrderService(dbDep, eventStore)
+	tokenParser := jwtauth.NewTokenParser(cfg.JWKSURL)
+	userServer := serverhttp.NewServer(orderService, app.NewPromoCodeService(dbDep), app.NewCatalogService(dbDep), tokenParser, logger)
+
+	idempotencyMiddleware := idempotency.NewMiddleware(
+		ctx,
+		idempotency.NewRepository(connector.Client()),
+		idempotency.NewTokenUserIDExtractor(tokenParser),
+		logger,
+	)
 
 	router := mux.NewRouter()
 	router.HandleFunc("/health", handleHealth).Methods(http.MethodGet)
 	router.HandleFunc("/ready", handleReady(connector)).Methods(http.MethodGet)
-	router.PathPrefix(serverhttp.PathPrefix).Handler(userServer.MakeHandler())
+	router.PathPrefix(serverhttp.PathPrefix).Handler(idempotencyMiddleware(userServer.MakeHandler()))
+	router.PathPrefix(serverhttp.PathPrefixInternal).Handler(userServer.MakeInternalHandler())
 
 	metricsHandler.AddMetricsHandler(router, "/metrics")
 	metricsHandler.AddCommonMetricsMiddleware(router)
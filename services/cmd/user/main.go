@@ -1,6 +1,7 @@
 package main
 
 import (
+	"arch-homework/pkg/common/infrastructure/idempotency"
 	"arch-homework/pkg/common/infrastructure/metrics"
 	commonpostgres "arch-homework/pkg/common/infrastructure/postgres"
 	"arch-homework/pkg/common/jwtauth"
@@ -47,7 +48,9 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	server := startServer(cfg, connector, logger, metricsHandler)
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	server := startServer(ctx, cfg, connector, logger, metricsHandler)
 
 	waitForKillSignal(logger)
 	if err := server.Shutdown(context.Background()); err != nil {
@@ -76,20 +79,33 @@ func waitForKillSignal(logger *logrus.Logger) {
 	logger.Infof("got system signal '%s'", <-sysKillSignal)
 }
 
-func startServer(cfg *config, connector commonpostgres.Connector, logger *logrus.Logger, metricsHandler metrics.PrometheusMetricsHandler) *http.Server {
+func startServer(
+	ctx context.Context,
+	cfg *config,
+	connector commonpostgres.Connector,
+	logger *logrus.Logger,
+	metricsHandler metrics.PrometheusMetricsHandler,
+) *http.Server {
 	httpAddress := ":" + cfg.ServicePort
 	if err := connector.WaitUntilReady(); err != nil {
 		logger.Fatal(err)
 	}
 	dbDependency := postgres.NewDBDependency(connector.Client())
 	userService := app.NewUserService(dbDependency)
-	tokenParser := jwtauth.NewTokenParser(cfg.JWTSecret)
+	tokenParser := jwtauth.NewTokenParser(cfg.JWKSURL)
 	userServer := serverhttp.NewServer(userService, tokenParser, logger)
 
+	idempotencyMiddleware := idempotency.NewMiddleware(
+		ctx,
+		idempotency.NewRepository(connector.Client()),
+		idempotency.NewTokenUserIDExtractor(tokenParser),
+		logger,
+	)
+
 	router := mux.NewRouter()
 	router.HandleFunc("/health", handleHealth).Methods(http.MethodGet)
 	router.HandleFunc("/ready", handleReady(connector)).Methods(http.MethodGet)
-	router.PathPrefix(serverhttp.PathPrefix).Handler(userServer.MakeHandler())
+	router.PathPrefix(serverhttp.PathPrefix).Handler(idempotencyMiddleware(userServer.MakeHandler()))
 
 	metricsHandler.AddMetricsHandler(router, "/metrics")
 	metricsHandler.AddCommonMetricsMiddleware(router)
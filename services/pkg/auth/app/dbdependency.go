@@ -4,11 +4,14 @@ import "arch-homework/pkg/common/app/storedevent"
 
 type RepositoryProvider interface {
 	UserRepository() UserRepository
+	SessionRepository() SessionRepository
+	TokenPairRepository() TokenPairRepository
 	EventStore() storedevent.EventStore
 }
 
 type ReadRepositoryProvider interface {
 	UserRepositoryRead() UserRepositoryRead
+	TokenPairRepositoryRead() TokenPairRepositoryRead
 }
 
 type TransactionalUnit interface {
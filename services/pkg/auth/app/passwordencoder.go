@@ -1,5 +1,10 @@
 package app
 
 type PasswordEncoder interface {
-	Encode(rawPassword string, userID UserID) Password
+	// Encode returns the hash in the encoded format recording the algorithm and its parameters,
+	// ErrPasswordTooLong is returned when the algorithm can not hash the whole password
+	Encode(rawPassword string, userID UserID) (Password, error)
+	// Verify checks the password against the encoded hash, needsRehash is set for the matching password
+	// when the hash is made with the legacy algorithm or outdated parameters
+	Verify(rawPassword string, userID UserID, encoded Password) (matches bool, needsRehash bool, err error)
 }
@@ -2,22 +2,99 @@ package app
 
 import (
 	"arch-homework/pkg/common/app/uuid"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"strings"
 	"time"
 )
 
-var ErrSessionNotFound = errors.New("user not found")
+var ErrSessionNotFound = errors.New("session not found")
+
+// PurgeBatchSize limits number of expired sessions removed by one query
+const PurgeBatchSize = 1000
+
+const maxUserAgentLen = 512
 
 type SessionID uuid.UUID
 
+// SessionPublicID identifies the session to its user, the session id itself is the cookie value
+// and must not be shown even to the same user
+type SessionPublicID string
+
 type Session struct {
-	ID        SessionID
-	UserID    UserID
-	ValidTill time.Time
+	ID     SessionID
+	UserID UserID
+	// ValidTill is moved on each use of the session, but not beyond the max lifetime since its creation
+	ValidTill    time.Time
+	CreationDate time.Time
+	LastUseDate  time.Time
+	// UserAgent and IP are of the client which used the session last
+	UserAgent string
+	IP        string
+}
+
+// ClientInfo describes the device the session is used from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionLifetime is configured separately for cookie sessions and token sessions
+type SessionLifetime struct {
+	// Idle is the sliding window, the session expires when not used during it
+	Idle time.Duration
+	// Max is the absolute lifetime since login regardless of the session use
+	Max time.Duration
+}
+
+func newSession(userID UserID, lifetime SessionLifetime, client ClientInfo) *Session {
+	session := &Session{
+		ID:           SessionID(uuid.GenerateNew()),
+		UserID:       userID,
+		CreationDate: time.Now(),
+	}
+	session.use(lifetime, client)
+	return session
+}
+
+func (s *Session) PublicID() SessionPublicID {
+	sum := sha256.Sum256([]byte(s.ID))
+	return SessionPublicID(hex.EncodeToString(sum[:16]))
+}
+
+func (s *Session) Expired() bool {
+	return !time.Now().Before(s.ValidTill)
+}
+
+// use extends the session and records the client using it
+func (s *Session) use(lifetime SessionLifetime, client ClientInfo) {
+	s.extend(lifetime)
+	s.LastUseDate = time.Now()
+	s.UserAgent = client.UserAgent
+	if len(s.UserAgent) > maxUserAgentLen {
+		s.UserAgent = strings.ToValidUTF8(s.UserAgent[:maxUserAgentLen], "")
+	}
+	s.IP = client.IP
+}
+
+func (s *Session) extend(lifetime SessionLifetime) {
+	validTill := time.Now().Add(lifetime.Idle)
+	if maxValidTill := s.CreationDate.Add(lifetime.Max); validTill.After(maxValidTill) {
+		validTill = maxValidTill
+	}
+	s.ValidTill = validTill
 }
 
 type SessionRepository interface {
 	Store(session *Session) error
+	// Remove revokes the session with all token pairs issued for it
 	Remove(id SessionID) error
 	FindByID(id SessionID) (*Session, error)
+	// FindByUserID returns sessions of the user including expired ones not purged yet
+	FindByUserID(userID UserID) ([]Session, error)
+	// RemoveUserSessionsExcept revokes all sessions of the user except the one and returns their number
+	RemoveUserSessionsExcept(userID UserID, exceptID SessionID) (int, error)
+	// RemoveExpired removes up to limit sessions expired before the time and returns their number
+	RemoveExpired(before time.Time, limit int) (int, error)
 }
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewSessionService(sessionRepo SessionRepository, lifetime SessionLifetime) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		lifetime:    lifetime,
+	}
+}
+
+// SessionService manages cookie sessions of browser clients
+type SessionService struct {
+	sessionRepo SessionRepository
+	lifetime    SessionLifetime
+}
+
+func (s *SessionService) StartSession(userID UserID, client ClientInfo) (*Session, error) {
+	session := newSession(userID, s.lifetime, client)
+	if err := s.sessionRepo.Store(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Authenticate returns the session extending its idle window, expired session is not found
+func (s *SessionService) Authenticate(id SessionID, client ClientInfo) (*Session, error) {
+	session, err := s.sessionRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Expired() {
+		return nil, errors.Wrapf(ErrSessionNotFound, "session %s expired", string(id))
+	}
+	session.use(s.lifetime, client)
+	if err = s.sessionRepo.Store(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *SessionService) Remove(id SessionID) error {
+	return s.sessionRepo.Remove(id)
+}
+
+// UserSessions returns active cookie and token sessions of the user, the recently used first
+func (s *SessionService) UserSessions(userID UserID) ([]Session, error) {
+	sessions, err := s.sessionRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		if !session.Expired() {
+			res = append(res, session)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastUseDate.After(res[j].LastUseDate)
+	})
+	return res, nil
+}
+
+// RevokeUserSession removes the session of the user, sessions of other users are not found
+func (s *SessionService) RevokeUserSession(userID UserID, publicID SessionPublicID) (SessionID, error) {
+	sessions, err := s.sessionRepo.FindByUserID(userID)
+	if err != nil {
+		return "", err
+	}
+	for _, session := range sessions {
+		if session.PublicID() == publicID {
+			return session.ID, s.sessionRepo.Remove(session.ID)
+		}
+	}
+	return "", errors.Wrapf(ErrSessionNotFound, "session %s", string(publicID))
+}
+
+// RevokeOtherUserSessions logs out all devices of the user except the current session
+func (s *SessionService) RevokeOtherUserSessions(userID UserID, currentID SessionID) (int, error) {
+	return s.sessionRepo.RemoveUserSessionsExcept(userID, currentID)
+}
+
+// PurgeExpired removes the batch of sessions expired before the time, token sessions are removed with their tokens
+func (s *SessionService) PurgeExpired(before time.Time) (int, error) {
+	return s.sessionRepo.RemoveExpired(before, PurgeBatchSize)
+}
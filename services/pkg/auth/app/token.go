@@ -0,0 +1,128 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/uuid"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var ErrTokenNotFound = errors.New("token not found")
+var ErrTokenExpired = errors.New("token expired")
+var ErrRefreshTokenReused = errors.New("refresh token reused, session revoked")
+
+const tokenSize = 32
+
+type TokenPairID uuid.UUID
+
+// TokenHash is sha256 of the token, tokens are given to the client only and never stored
+type TokenHash string
+
+// TokenPair is access and refresh tokens issued for the session, refresh exchanges the refresh token
+// for the next pair, so the session is the family of pairs and only the last pair is usable
+type TokenPair struct {
+	ID                 TokenPairID
+	SessionID          SessionID
+	UserID             UserID
+	AccessToken        TokenHash
+	RefreshToken       TokenHash
+	AccessTokenExpiry  time.Time
+	RefreshTokenExpiry time.Time
+	// RefreshDate is set when the pair is exchanged for the next one, the second exchange means the token is stolen
+	RefreshDate  *time.Time
+	CreationDate time.Time
+}
+
+// IssuedTokens are returned to the client once, only their hashes are stored
+type IssuedTokens struct {
+	AccessToken       string
+	RefreshToken      string
+	AccessTokenExpiry time.Time
+}
+
+// TokenLifetime is configured for token sessions of non-browser clients
+type TokenLifetime struct {
+	Access time.Duration
+	// Session idle window is the refresh token lifetime, each refresh moves it
+	Session SessionLifetime
+}
+
+// newTokenPair issues tokens valid not longer than the session
+func newTokenPair(session *Session, accessTokenLifetime time.Duration) (*TokenPair, *IssuedTokens, error) {
+	accessToken, err := generateToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := generateToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	accessTokenExpiry := now.Add(accessTokenLifetime)
+	if accessTokenExpiry.After(session.ValidTill) {
+		accessTokenExpiry = session.ValidTill
+	}
+	pair := &TokenPair{
+		ID:                 TokenPairID(uuid.GenerateNew()),
+		SessionID:          session.ID,
+		UserID:             session.UserID,
+		AccessToken:        HashToken(accessToken),
+		RefreshToken:       HashToken(refreshToken),
+		AccessTokenExpiry:  accessTokenExpiry,
+		RefreshTokenExpiry: session.ValidTill,
+		CreationDate:       now,
+	}
+	tokens := &IssuedTokens{
+		AccessToken:       accessToken,
+		RefreshToken:      refreshToken,
+		AccessTokenExpiry: pair.AccessTokenExpiry,
+	}
+	return pair, tokens, nil
+}
+
+func (p *TokenPair) checkAccess() error {
+	if p.RefreshDate != nil || !time.Now().Before(p.AccessTokenExpiry) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+func (p *TokenPair) refresh() error {
+	if p.RefreshDate != nil {
+		return ErrRefreshTokenReused
+	}
+	if !time.Now().Before(p.RefreshTokenExpiry) {
+		return ErrTokenExpired
+	}
+	now := time.Now()
+	p.RefreshDate = &now
+	return nil
+}
+
+func HashToken(token string) TokenHash {
+	sum := sha256.Sum256([]byte(token))
+	return TokenHash(hex.EncodeToString(sum[:]))
+}
+
+func generateToken() (string, error) {
+	data := make([]byte, tokenSize)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+type TokenPairRepositoryRead interface {
+	FindByAccessToken(hash TokenHash) (*TokenPair, error)
+	FindByRefreshToken(hash TokenHash) (*TokenPair, error)
+}
+
+type TokenPairRepository interface {
+	TokenPairRepositoryRead
+	// FindByRefreshTokenForUpdate locks the pair, so concurrent refreshes with the same token are detected as reuse
+	FindByRefreshTokenForUpdate(hash TokenHash) (*TokenPair, error)
+	Store(pair *TokenPair) error
+}
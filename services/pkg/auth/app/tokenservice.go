@@ -0,0 +1,128 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+)
+
+func NewTokenService(dbDependency DBDependency, lifetime TokenLifetime) *TokenService {
+	return &TokenService{
+		readRepo:      dbDependency.TokenPairRepositoryRead(),
+		trUnitFactory: dbDependency,
+		lifetime:      lifetime,
+	}
+}
+
+// TokenService authenticates clients not able to keep the session cookie,
+// each token session is stored as the session, so its revocation removes all token pairs of it
+type TokenService struct {
+	readRepo      TokenPairRepositoryRead
+	trUnitFactory TransactionalUnitFactory
+	lifetime      TokenLifetime
+}
+
+// IssueTokens starts the new session of the user with the first token pair
+func (s *TokenService) IssueTokens(userID UserID, client ClientInfo) (*IssuedTokens, error) {
+	session := newSession(userID, s.lifetime.Session, client)
+	pair, tokens, err := newTokenPair(session, s.lifetime.Access)
+	if err != nil {
+		return nil, err
+	}
+	err = s.executeInTransaction(func(provider RepositoryProvider) error {
+		if err2 := provider.SessionRepository().Store(session); err2 != nil {
+			return err2
+		}
+		return provider.TokenPairRepository().Store(pair)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RefreshTokens exchanges the refresh token for the next pair, the session is revoked
+// when already exchanged token is used again as it is unknown whether the client or the attacker holds the last pair.
+// Token session last use is recorded on refresh only, so requests with the access token do not write to the database
+func (s *TokenService) RefreshTokens(refreshToken string, client ClientInfo) (*IssuedTokens, error) {
+	var tokens *IssuedTokens
+	reused := false
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		pair, err := provider.TokenPairRepository().FindByRefreshTokenForUpdate(HashToken(refreshToken))
+		if err != nil {
+			return err
+		}
+		if err = pair.refresh(); err != nil {
+			if errors.Cause(err) == ErrRefreshTokenReused {
+				reused = true
+				return provider.SessionRepository().Remove(pair.SessionID)
+			}
+			return err
+		}
+		session, err := provider.SessionRepository().FindByID(pair.SessionID)
+		if err != nil {
+			return err
+		}
+		// the refresh token may outlive the session reaching its max lifetime
+		if session.Expired() {
+			return errors.WithStack(ErrTokenExpired)
+		}
+		session.use(s.lifetime.Session, client)
+		var nextPair *TokenPair
+		nextPair, tokens, err = newTokenPair(session, s.lifetime.Access)
+		if err != nil {
+			return err
+		}
+		if err = provider.SessionRepository().Store(session); err != nil {
+			return err
+		}
+		if err = provider.TokenPairRepository().Store(pair); err != nil {
+			return err
+		}
+		return provider.TokenPairRepository().Store(nextPair)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, errors.WithStack(ErrRefreshTokenReused)
+	}
+	return tokens, nil
+}
+
+// Authenticate returns the last token pair of the session the access token is issued for
+func (s *TokenService) Authenticate(accessToken string) (*TokenPair, error) {
+	pair, err := s.readRepo.FindByAccessToken(HashToken(accessToken))
+	if err != nil {
+		return nil, err
+	}
+	if err = pair.checkAccess(); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Revoke removes the session of the refresh token, unknown tokens are ignored as they are already revoked
+func (s *TokenService) Revoke(refreshToken string) error {
+	pair, err := s.readRepo.FindByRefreshToken(HashToken(refreshToken))
+	if err != nil {
+		if errors.Cause(err) == ErrTokenNotFound {
+			return nil
+		}
+		return err
+	}
+	return s.executeInTransaction(func(provider RepositoryProvider) error {
+		return provider.SessionRepository().Remove(pair.SessionID)
+	})
+}
+
+func (s *TokenService) executeInTransaction(f func(RepositoryProvider) error) (err error) {
+	var trUnit TransactionalUnit
+	trUnit, err = s.trUnitFactory.NewTransactionalUnit()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = trUnit.Complete(err)
+	}()
+	err = f(trUnit)
+	return err
+}
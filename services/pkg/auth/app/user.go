@@ -9,6 +9,7 @@ var ErrUserNotFound = errors.New("user not found")
 var ErrLoginTooLong = errors.New("login too long")
 var ErrLoginAlreadyExists = errors.New("login already exists")
 var ErrInvalidPassword = errors.New("invalid password")
+var ErrPasswordTooLong = errors.New("password too long")
 
 type UserID uuid.UUID
 type Login string
@@ -31,13 +31,17 @@ func (s *UserService) Add(login, password string) (UserID, error) {
 	}
 
 	id := UserID(uuid.GenerateNew())
+	encodedPassword, err := s.passwordEncoder.Encode(password, id)
+	if err != nil {
+		return "", err
+	}
 	user := User{
 		UserID:   id,
 		Login:    Login(login),
-		Password: s.passwordEncoder.Encode(password, id),
+		Password: encodedPassword,
 	}
 
-	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+	err = s.executeInTransaction(func(provider RepositoryProvider) error {
 		err2 := provider.UserRepository().Store(&user)
 		if err2 != nil {
 			return err2
@@ -70,18 +74,40 @@ func (s *UserService) FindUserByID(id UserID) (*User, error) {
 	return s.readRepo.FindByID(id)
 }
 
+// FindUserByLoginAndPassword verifies the password, hash made with the legacy algorithm or outdated parameters
+// is replaced with the current one as the password is known only on login
 func (s *UserService) FindUserByLoginAndPassword(login, password string) (*User, error) {
 	user, err := s.readRepo.FindByLogin(Login(login))
 	if err != nil {
 		return nil, err
 	}
-	encodedPass := s.passwordEncoder.Encode(password, user.UserID)
-	if encodedPass != user.Password {
+	matches, needsRehash, err := s.passwordEncoder.Verify(password, user.UserID, user.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !matches {
 		return nil, ErrInvalidPassword
 	}
+	if needsRehash {
+		// password too long for the current algorithm keeps its hash
+		if err = s.rehashPassword(user, password); err != nil && errors.Cause(err) != ErrPasswordTooLong {
+			return nil, err
+		}
+	}
 	return user, nil
 }
 
+func (s *UserService) rehashPassword(user *User, password string) error {
+	encodedPassword, err := s.passwordEncoder.Encode(password, user.UserID)
+	if err != nil {
+		return err
+	}
+	user.Password = encodedPassword
+	return s.executeInTransaction(func(provider RepositoryProvider) error {
+		return provider.UserRepository().Store(user)
+	})
+}
+
 func (s *UserService) checkLogin(login Login) error {
 	if len(login) > maxLoginLen {
 		return errors.Wrapf(ErrLoginTooLong, "max login length (%d symbols) exceeded", maxLoginLen)
@@ -2,19 +2,68 @@ package encoding
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
 	"fmt"
+	"strings"
 
 	"arch-homework/pkg/auth/app"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
 )
 
-func NewPasswordEncoder() app.PasswordEncoder {
-	return sha256PasswordEncoder{}
+// bcryptPrefix starts every bcrypt hash, the hash records the algorithm version, cost and salt, e.g. "$2a$10$..."
+const bcryptPrefix = "$2"
+
+// maxPasswordLen is the bcrypt input limit, longer passwords are rejected as bcrypt ignores the rest of them
+const maxPasswordLen = 72
+
+// NewPasswordEncoder encodes passwords with bcrypt, legacy sha256 hashes are still verified
+func NewPasswordEncoder(cost int) (app.PasswordEncoder, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, errors.Errorf("bcrypt cost should be in range %d..%d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return bcryptPasswordEncoder{cost: cost}, nil
+}
+
+type bcryptPasswordEncoder struct {
+	cost int
+}
+
+func (e bcryptPasswordEncoder) Encode(rawPassword string, _ app.UserID) (app.Password, error) {
+	if len(rawPassword) > maxPasswordLen {
+		return "", errors.Wrapf(app.ErrPasswordTooLong, "max password length (%d bytes) exceeded", maxPasswordLen)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(rawPassword), e.cost)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return app.Password(hash), nil
 }
 
-type sha256PasswordEncoder struct {
+func (e bcryptPasswordEncoder) Verify(rawPassword string, userID app.UserID, encoded app.Password) (bool, bool, error) {
+	if !strings.HasPrefix(string(encoded), bcryptPrefix) {
+		matches := verifyLegacySHA256(rawPassword, userID, encoded)
+		return matches, matches, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(rawPassword))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, errors.WithStack(err)
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, errors.WithStack(err)
+	}
+	return true, cost != e.cost, nil
 }
 
-func (m sha256PasswordEncoder) Encode(rawPassword string, userID app.UserID) app.Password {
+// verifyLegacySHA256 checks hashes stored before bcrypt as hex of sha256(userID + password)
+func verifyLegacySHA256(rawPassword string, userID app.UserID, encoded app.Password) bool {
 	data := []byte(string(userID) + rawPassword)
-	return app.Password(fmt.Sprintf("%x", sha256.Sum256(data)))
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(encoded)) == 1
 }
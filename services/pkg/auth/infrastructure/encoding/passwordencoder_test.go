@@ -0,0 +1,69 @@
+package encoding
+
+import (
+	"arch-homework/pkg/auth/app"
+
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		name        string
+		password    string
+		expectedErr error
+	}{
+		{name: "short password", password: "user1-pwd"},
+		{name: "max length password", password: strings.Repeat("a", maxPasswordLen)},
+		{name: "max length multibyte password", password: strings.Repeat("я", maxPasswordLen/2)},
+		{name: "too long password", password: strings.Repeat("a", maxPasswordLen+1), expectedErr: app.ErrPasswordTooLong},
+		{name: "too long multibyte password", password: strings.Repeat("я", maxPasswordLen/2+1), expectedErr: app.ErrPasswordTooLong},
+	}
+
+	encoder, err := NewPasswordEncoder(bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			encoded, err := encoder.Encode(testCase.password, "user")
+			if errors.Cause(err) != testCase.expectedErr {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			matches, needsRehash, err := encoder.Verify(testCase.password, "user", encoded)
+			if err != nil || !matches || needsRehash {
+				t.Errorf("expected password to match without rehash, got matches %v, rehash %v, error %v", matches, needsRehash, err)
+			}
+			// the last byte takes part in the hash
+			if matches, _, _ = encoder.Verify(testCase.password[:len(testCase.password)-1]+"b", "user", encoded); matches {
+				t.Error("expected changed password not to match")
+			}
+		})
+	}
+}
+
+func TestVerifyLegacyHash(t *testing.T) {
+	const userID app.UserID = "user"
+	password := strings.Repeat("a", maxPasswordLen+1)
+	encoded := app.Password(fmt.Sprintf("%x", sha256.Sum256([]byte(string(userID)+password))))
+
+	encoder, err := NewPasswordEncoder(bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, needsRehash, err := encoder.Verify(password, userID, encoded)
+	if err != nil || !matches || !needsRehash {
+		t.Errorf("expected legacy password to match with rehash, got matches %v, rehash %v, error %v", matches, needsRehash, err)
+	}
+	if matches, _, _ = encoder.Verify(password[:maxPasswordLen], userID, encoded); matches {
+		t.Error("expected truncated password not to match")
+	}
+}
@@ -28,6 +28,10 @@ func (d *dbDependency) UserRepositoryRead() app.UserRepositoryRead {
 	return NewUserRepository(d.client)
 }
 
+func (d *dbDependency) TokenPairRepositoryRead() app.TokenPairRepositoryRead {
+	return NewTokenPairRepository(d.client)
+}
+
 type transactionalUnit struct {
 	transaction postgres.Transaction
 }
@@ -40,6 +44,14 @@ func (t *transactionalUnit) UserRepository() app.UserRepository {
 	return NewUserRepository(t.transaction)
 }
 
+func (t *transactionalUnit) SessionRepository() app.SessionRepository {
+	return NewSessionRepository(t.transaction)
+}
+
+func (t *transactionalUnit) TokenPairRepository() app.TokenPairRepository {
+	return NewTokenPairRepository(t.transaction)
+}
+
 func (t *transactionalUnit) Complete(err error) error {
 	if err != nil {
 		rollbackErr := t.transaction.Rollback()
@@ -10,6 +10,8 @@ import (
 	"arch-homework/pkg/common/infrastructure/postgres"
 )
 
+const selectSessionQuery = `SELECT id, user_id, valid_till, created_at, last_used_at, user_agent, ip FROM session`
+
 func NewSessionRepository(client postgres.Client) app.SessionRepository {
 	return &sessionRepository{client: client}
 }
@@ -20,17 +22,24 @@ type sessionRepository struct {
 
 func (repo *sessionRepository) Store(session *app.Session) error {
 	const query = `
-			INSERT INTO session (id, user_id, valid_till)
-			VALUES (:id, :user_id, :valid_till)
+			INSERT INTO session (id, user_id, valid_till, created_at, last_used_at, user_agent, ip)
+			VALUES (:id, :user_id, :valid_till, :created_at, :last_used_at, :user_agent, :ip)
 			ON CONFLICT (id) DO UPDATE SET
 				user_id = excluded.user_id,
-				valid_till = excluded.valid_till
+				valid_till = excluded.valid_till,
+				last_used_at = excluded.last_used_at,
+				user_agent = excluded.user_agent,
+				ip = excluded.ip
 		`
 
 	sessionx := sqlxSession{
-		ID:        string(session.ID),
-		UserID:    string(session.UserID),
-		ValidTill: session.ValidTill,
+		ID:           string(session.ID),
+		UserID:       string(session.UserID),
+		ValidTill:    session.ValidTill,
+		CreationDate: session.CreationDate,
+		LastUseDate:  session.LastUseDate,
+		UserAgent:    session.UserAgent,
+		IP:           session.IP,
 	}
 
 	_, err := repo.client.NamedExec(query, &sessionx)
@@ -44,7 +53,7 @@ func (repo *sessionRepository) Remove(id app.SessionID) error {
 }
 
 func (repo *sessionRepository) FindByID(id app.SessionID) (*app.Session, error) {
-	const query = `SELECT id, user_id, valid_till FROM session WHERE id = $1`
+	const query = selectSessionQuery + ` WHERE id = $1`
 
 	var session sqlxSession
 	err := repo.client.Get(&session, query, string(id))
@@ -58,16 +67,63 @@ func (repo *sessionRepository) FindByID(id app.SessionID) (*app.Session, error)
 	return &res, nil
 }
 
+func (repo *sessionRepository) FindByUserID(userID app.UserID) ([]app.Session, error) {
+	const query = selectSessionQuery + ` WHERE user_id = $1`
+
+	var sessions []*sqlxSession
+	err := repo.client.Select(&sessions, query, string(userID))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	res := make([]app.Session, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, sqlxSessionToSession(session))
+	}
+	return res, nil
+}
+
+func (repo *sessionRepository) RemoveUserSessionsExcept(userID app.UserID, exceptID app.SessionID) (int, error) {
+	const query = `DELETE FROM session WHERE user_id = $1 AND id <> $2`
+	result, err := repo.client.Exec(query, string(userID), string(exceptID))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), errors.WithStack(err)
+}
+
+func (repo *sessionRepository) RemoveExpired(before time.Time, limit int) (int, error) {
+	// token pairs of removed sessions are removed by the foreign key cascade
+	const query = `
+			DELETE FROM session
+			WHERE id IN (SELECT id FROM session WHERE valid_till < $1 LIMIT $2)
+		`
+	result, err := repo.client.Exec(query, before, limit)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), errors.WithStack(err)
+}
+
 func sqlxSessionToSession(session *sqlxSession) app.Session {
 	return app.Session{
-		ID:        app.SessionID(session.ID),
-		UserID:    app.UserID(session.UserID),
-		ValidTill: session.ValidTill,
+		ID:           app.SessionID(session.ID),
+		UserID:       app.UserID(session.UserID),
+		ValidTill:    session.ValidTill,
+		CreationDate: session.CreationDate,
+		LastUseDate:  session.LastUseDate,
+		UserAgent:    session.UserAgent,
+		IP:           session.IP,
 	}
 }
 
 type sqlxSession struct {
-	ID        string    `db:"id"`
-	UserID    string    `db:"user_id"`
-	ValidTill time.Time `db:"valid_till"`
+	ID           string    `db:"id"`
+	UserID       string    `db:"user_id"`
+	ValidTill    time.Time `db:"valid_till"`
+	CreationDate time.Time `db:"created_at"`
+	LastUseDate  time.Time `db:"last_used_at"`
+	UserAgent    string    `db:"user_agent"`
+	IP           string    `db:"ip"`
 }
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"arch-homework/pkg/auth/app"
+	"arch-homework/pkg/common/infrastructure/postgres"
+)
+
+const selectTokenPairQuery = `
+		SELECT id, session_id, user_id, access_token_hash, refresh_token_hash, access_token_expires_at,
+			refresh_token_expires_at, refreshed_at, created_at
+		FROM token_pair`
+
+func NewTokenPairRepository(client postgres.Client) app.TokenPairRepository {
+	return &tokenPairRepository{client: client}
+}
+
+type tokenPairRepository struct {
+	client postgres.Client
+}
+
+func (repo *tokenPairRepository) Store(pair *app.TokenPair) error {
+	const query = `
+			INSERT INTO token_pair (id, session_id, user_id, access_token_hash, refresh_token_hash, access_token_expires_at,
+				refresh_token_expires_at, refreshed_at, created_at)
+			VALUES (:id, :session_id, :user_id, :access_token_hash, :refresh_token_hash, :access_token_expires_at,
+				:refresh_token_expires_at, :refreshed_at, :created_at)
+			ON CONFLICT (id) DO UPDATE SET
+				refreshed_at = excluded.refreshed_at
+		`
+
+	pairx := sqlxTokenPair{
+		ID:                 string(pair.ID),
+		SessionID:          string(pair.SessionID),
+		UserID:             string(pair.UserID),
+		AccessToken:        string(pair.AccessToken),
+		RefreshToken:       string(pair.RefreshToken),
+		AccessTokenExpiry:  pair.AccessTokenExpiry,
+		RefreshTokenExpiry: pair.RefreshTokenExpiry,
+		CreationDate:       pair.CreationDate,
+	}
+	if pair.RefreshDate != nil {
+		pairx.RefreshDate = sql.NullTime{Time: *pair.RefreshDate, Valid: true}
+	}
+
+	_, err := repo.client.NamedExec(query, &pairx)
+	return errors.WithStack(err)
+}
+
+func (repo *tokenPairRepository) FindByAccessToken(hash app.TokenHash) (*app.TokenPair, error) {
+	return repo.find(selectTokenPairQuery+` WHERE access_token_hash = $1`, string(hash))
+}
+
+func (repo *tokenPairRepository) FindByRefreshToken(hash app.TokenHash) (*app.TokenPair, error) {
+	return repo.find(selectTokenPairQuery+` WHERE refresh_token_hash = $1`, string(hash))
+}
+
+func (repo *tokenPairRepository) FindByRefreshTokenForUpdate(hash app.TokenHash) (*app.TokenPair, error) {
+	return repo.find(selectTokenPairQuery+` WHERE refresh_token_hash = $1 FOR UPDATE`, string(hash))
+}
+
+func (repo *tokenPairRepository) find(query string, param string) (*app.TokenPair, error) {
+	var pair sqlxTokenPair
+	err := repo.client.Get(&pair, query, param)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.WithStack(app.ErrTokenNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	res := sqlxTokenPairToTokenPair(&pair)
+	return &res, nil
+}
+
+func sqlxTokenPairToTokenPair(pair *sqlxTokenPair) app.TokenPair {
+	res := app.TokenPair{
+		ID:                 app.TokenPairID(pair.ID),
+		SessionID:          app.SessionID(pair.SessionID),
+		UserID:             app.UserID(pair.UserID),
+		AccessToken:        app.TokenHash(pair.AccessToken),
+		RefreshToken:       app.TokenHash(pair.RefreshToken),
+		AccessTokenExpiry:  pair.AccessTokenExpiry,
+		RefreshTokenExpiry: pair.RefreshTokenExpiry,
+		CreationDate:       pair.CreationDate,
+	}
+	if pair.RefreshDate.Valid {
+		refreshDate := pair.RefreshDate.Time
+		res.RefreshDate = &refreshDate
+	}
+	return res
+}
+
+type sqlxTokenPair struct {
+	ID                 string       `db:"id"`
+	SessionID          string       `db:"session_id"`
+	UserID             string       `db:"user_id"`
+	AccessToken        string       `db:"access_token_hash"`
+	RefreshToken       string       `db:"refresh_token_hash"`
+	AccessTokenExpiry  time.Time    `db:"access_token_expires_at"`
+	RefreshTokenExpiry time.Time    `db:"refresh_token_expires_at"`
+	RefreshDate        sql.NullTime `db:"refreshed_at"`
+	CreationDate       time.Time    `db:"created_at"`
+}
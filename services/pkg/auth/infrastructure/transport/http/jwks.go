@@ -0,0 +1,29 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"arch-homework/pkg/common/jwtauth"
+)
+
+// JWKSEndpoint is internal, services fetch keys from it to verify tokens generated by the auth service
+const JWKSEndpoint = "/.well-known/jwks.json"
+
+// keySetMaxAge lets caches keep the key set shorter than token parsers do
+const keySetMaxAge = "max-age=60"
+
+func MakeJWKSHandler(jwks jwtauth.JWKS) (http.HandlerFunc, error) {
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", keySetMaxAge)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+	}, nil
+}
@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
-	"time"
 
 	"arch-homework/pkg/auth/app"
 	"arch-homework/pkg/common/app/uuid"
@@ -23,6 +22,9 @@ const (
 	authEndpoint         = PathPrefix + "auth"
 	loginEndpoint        = PathPrefix + "login"
 	logoutEndpoint       = PathPrefix + "logout"
+	tokenEndpoint        = PathPrefix + "token"
+	sessionsEndpoint     = PathPrefix + "sessions"
+	sessionEndpoint      = PathPrefix + "sessions/{id}"
 )
 
 const (
@@ -31,18 +33,42 @@ const (
 	errorCodeUsernameAlreadyExists = 2
 	errorCodeUsernameTooLong       = 3
 	errorCodeInvalidPassword       = 4
+	errorCodeInvalidGrantType      = 5
+	errorCodeInvalidToken          = 6
+	errorCodeRefreshTokenReused    = 7
+	errorCodeSessionNotFound       = 8
+	errorCodePasswordTooLong       = 9
 )
 
 const sessionCookieName = "session_id"
-const sessionLifetime = time.Minute * 30
 const authTokenHeader = "X-Auth-Token"
 
 var errUnauthorized = errors.New("not authorized")
 
-func NewServer(userService *app.UserService, sessionRepo app.SessionRepository, tokenGenerator jwtauth.TokenGenerator, logger *logrus.Logger) *Server {
+// endpointsWithSecrets get passwords or tokens in the request body, so the body is not logged for them
+var endpointsWithSecrets = map[string]bool{
+	registerUserEndpoint: true,
+	loginEndpoint:        true,
+	logoutEndpoint:       true,
+	tokenEndpoint:        true,
+}
+
+// secretHeaders carry the session id or the access token and are masked in logs
+var secretHeaders = []string{"Authorization", "Cookie"}
+
+const redactedValue = "[REDACTED]"
+
+func NewServer(
+	userService *app.UserService,
+	tokenService *app.TokenService,
+	sessionService *app.SessionService,
+	tokenGenerator jwtauth.TokenGenerator,
+	logger *logrus.Logger,
+) *Server {
 	return &Server{
 		userService:    userService,
-		sessionRepo:    sessionRepo,
+		tokenService:   tokenService,
+		sessionService: sessionService,
 		tokenGenerator: tokenGenerator,
 		logger:         logger,
 	}
@@ -50,7 +76,8 @@ func NewServer(userService *app.UserService, sessionRepo app.SessionRepository,
 
 type Server struct {
 	userService    *app.UserService
-	sessionRepo    app.SessionRepository
+	tokenService   *app.TokenService
+	sessionService *app.SessionService
 	tokenGenerator jwtauth.TokenGenerator
 	logger         *logrus.Logger
 }
@@ -61,6 +88,10 @@ func (s *Server) MakeHandler() http.Handler {
 	router.Methods(http.MethodPost).Path(registerUserEndpoint).Handler(s.makeHandlerFunc(s.registerUserHandler))
 	router.Methods(http.MethodPost).Path(loginEndpoint).Handler(s.makeHandlerFunc(s.loginHandler))
 	router.Methods(http.MethodPost).Path(logoutEndpoint).Handler(s.makeHandlerFunc(s.logoutHandler))
+	router.Methods(http.MethodPost).Path(tokenEndpoint).Handler(s.makeHandlerFunc(s.tokenHandler))
+	router.Methods(http.MethodGet).Path(sessionsEndpoint).Handler(s.makeHandlerFunc(s.listSessionsHandler))
+	router.Methods(http.MethodDelete).Path(sessionsEndpoint).Handler(s.makeHandlerFunc(s.revokeOtherSessionsHandler))
+	router.Methods(http.MethodDelete).Path(sessionEndpoint).Handler(s.makeHandlerFunc(s.revokeSessionHandler))
 	router.Path(authEndpoint).Handler(s.makeHandlerFunc(s.authHandler))
 
 	return router
@@ -77,8 +108,12 @@ func (s *Server) makeHandlerFunc(fn func(http.ResponseWriter, *http.Request) err
 		if r.URL.RawQuery != "" {
 			fields["query"] = r.URL.RawQuery
 		}
+		hasSecrets := endpointsWithSecrets[r.URL.Path]
 		if r.PostForm != nil {
 			fields["post"] = r.PostForm
+			if hasSecrets {
+				fields["post"] = redactedValue
+			}
 		}
 
 		if r.Body != nil {
@@ -87,9 +122,12 @@ func (s *Server) makeHandlerFunc(fn func(http.ResponseWriter, *http.Request) err
 			if len(bytesBody) > 0 {
 				r.Body = ioutil.NopCloser(bytes.NewBuffer(bytesBody))
 				fields["body"] = string(bytesBody)
+				if hasSecrets {
+					fields["body"] = redactedValue
+				}
 			}
 		}
-		headersBytes, _ := json.Marshal(r.Header)
+		headersBytes, _ := json.Marshal(maskSecretHeaders(r.Header))
 		fields["headers"] = string(headersBytes)
 
 		err := fn(w, r)
@@ -105,6 +143,16 @@ func (s *Server) makeHandlerFunc(fn func(http.ResponseWriter, *http.Request) err
 	}
 }
 
+func maskSecretHeaders(header http.Header) http.Header {
+	masked := header.Clone()
+	for _, name := range secretHeaders {
+		if _, ok := masked[name]; ok {
+			masked.Set(name, redactedValue)
+		}
+	}
+	return masked
+}
+
 func (s *Server) registerUserHandler(w http.ResponseWriter, r *http.Request) error {
 	var info userAuthData
 	bytesBody, err := ioutil.ReadAll(r.Body)
@@ -139,12 +187,7 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	session := app.Session{
-		ID:        app.SessionID(uuid.GenerateNew()),
-		UserID:    user.UserID,
-		ValidTill: time.Now().Add(sessionLifetime),
-	}
-	err = s.sessionRepo.Store(&session)
+	session, err := s.sessionService.StartSession(user.UserID, getClientInfo(r))
 	if err != nil {
 		return err
 	}
@@ -155,8 +198,27 @@ func (s *Server) loginHandler(w http.ResponseWriter, r *http.Request) error {
 }
 
 func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) error {
+	if accessToken, ok := getBearerToken(r); ok {
+		if pair, err := s.tokenService.Authenticate(accessToken); err == nil {
+			if err = s.sessionService.Remove(pair.SessionID); err != nil {
+				return err
+			}
+		}
+	}
+	var request logoutRequest
+	if bytesBody, err := ioutil.ReadAll(r.Body); err == nil && len(bytesBody) > 0 {
+		_ = r.Body.Close()
+		if err = json.Unmarshal(bytesBody, &request); err != nil {
+			return err
+		}
+	}
+	if request.RefreshToken != "" {
+		if err := s.tokenService.Revoke(request.RefreshToken); err != nil {
+			return err
+		}
+	}
 	if sessionID, err := getSessionIDFromRequest(r); err == nil {
-		err = s.sessionRepo.Remove(sessionID)
+		err = s.sessionService.Remove(sessionID)
 		if err != nil {
 			return err
 		}
@@ -168,24 +230,45 @@ func (s *Server) logoutHandler(w http.ResponseWriter, r *http.Request) error {
 }
 
 func (s *Server) authHandler(w http.ResponseWriter, r *http.Request) error {
-	sessionID, err := getSessionIDFromRequest(r)
+	userID, _, err := s.authenticate(r)
 	if err != nil {
-		return errUnauthorized
+		return err
 	}
-	session, err := s.sessionRepo.FindByID(sessionID)
+	user, err := s.userService.FindUserByID(userID)
 	if err != nil {
-		if errors.Cause(err) == app.ErrSessionNotFound {
-			return errUnauthorized
-		}
 		return err
 	}
-	user, err := s.userService.FindUserByID(session.UserID)
+
+	return s.writeAuthToken(w, user)
+}
+
+// authenticate finds the current session by the bearer access token or by the session cookie
+func (s *Server) authenticate(r *http.Request) (app.UserID, app.SessionID, error) {
+	if accessToken, ok := getBearerToken(r); ok {
+		pair, err := s.tokenService.Authenticate(accessToken)
+		if err != nil {
+			if cause := errors.Cause(err); cause == app.ErrTokenNotFound || cause == app.ErrTokenExpired {
+				return "", "", errUnauthorized
+			}
+			return "", "", err
+		}
+		return pair.UserID, pair.SessionID, nil
+	}
+	sessionID, err := getSessionIDFromRequest(r)
 	if err != nil {
-		return err
+		return "", "", errUnauthorized
 	}
-	session.ValidTill = time.Now().Add(sessionLifetime)
-	_ = s.sessionRepo.Store(session)
+	session, err := s.sessionService.Authenticate(sessionID, getClientInfo(r))
+	if err != nil {
+		if errors.Cause(err) == app.ErrSessionNotFound {
+			return "", "", errUnauthorized
+		}
+		return "", "", err
+	}
+	return session.UserID, session.ID, nil
+}
 
+func (s *Server) writeAuthToken(w http.ResponseWriter, user *app.User) error {
 	token, err := s.tokenGenerator.GenerateToken(string(user.UserID), string(user.Login))
 	if err != nil {
 		return err
@@ -249,6 +332,21 @@ func writeErrorResponse(w http.ResponseWriter, err error) {
 	case app.ErrInvalidPassword:
 		info.Code = errorCodeInvalidPassword
 		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrPasswordTooLong:
+		info.Code = errorCodePasswordTooLong
+		w.WriteHeader(http.StatusBadRequest)
+	case errInvalidGrantType:
+		info.Code = errorCodeInvalidGrantType
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrTokenNotFound, app.ErrTokenExpired:
+		info.Code = errorCodeInvalidToken
+		w.WriteHeader(http.StatusUnauthorized)
+	case app.ErrRefreshTokenReused:
+		info.Code = errorCodeRefreshTokenReused
+		w.WriteHeader(http.StatusUnauthorized)
+	case app.ErrSessionNotFound:
+		info.Code = errorCodeSessionNotFound
+		w.WriteHeader(http.StatusNotFound)
 	case errUnauthorized:
 		w.WriteHeader(http.StatusUnauthorized)
 	default:
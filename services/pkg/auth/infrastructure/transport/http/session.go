@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"arch-homework/pkg/auth/app"
+)
+
+const (
+	userAgentHeader    = "User-Agent"
+	forwardedForHeader = "X-Forwarded-For"
+)
+
+func (s *Server) listSessionsHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, currentID, err := s.authenticate(r)
+	if err != nil {
+		return err
+	}
+	sessions, err := s.sessionService.UserSessions(userID)
+	if err != nil {
+		return err
+	}
+	infos := make([]sessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, sessionInfo{
+			ID:         string(session.PublicID()),
+			Current:    session.ID == currentID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreationDate.Format(time.RFC3339),
+			LastUsedAt: session.LastUseDate.Format(time.RFC3339),
+			ExpiresAt:  session.ValidTill.Format(time.RFC3339),
+		})
+	}
+	writeResponse(w, sessionListResponse{Sessions: infos})
+	return nil
+}
+
+func (s *Server) revokeSessionHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, currentID, err := s.authenticate(r)
+	if err != nil {
+		return err
+	}
+	publicID := app.SessionPublicID(mux.Vars(r)["id"])
+	sessionID, err := s.sessionService.RevokeUserSession(userID, publicID)
+	if err != nil {
+		return err
+	}
+	if sessionID == currentID {
+		setSessionCookie(w, nil)
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) revokeOtherSessionsHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, currentID, err := s.authenticate(r)
+	if err != nil {
+		return err
+	}
+	revoked, err := s.sessionService.RevokeOtherUserSessions(userID, currentID)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, revokedSessionsResponse{Revoked: revoked})
+	return nil
+}
+
+// getClientInfo takes the client address from the header set by the ingress,
+// the address is shown to the user only and is not trusted for anything else
+func getClientInfo(r *http.Request) app.ClientInfo {
+	ip := ""
+	if forwardedFor := r.Header.Get(forwardedForHeader); forwardedFor != "" {
+		ip = strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+	} else if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	return app.ClientInfo{
+		UserAgent: r.Header.Get(userAgentHeader),
+		IP:        ip,
+	}
+}
+
+type sessionInfo struct {
+	ID         string `json:"id"`
+	Current    bool   `json:"current"`
+	UserAgent  string `json:"userAgent"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"createdAt"`
+	LastUsedAt string `json:"lastUsedAt"`
+	ExpiresAt  string `json:"expiresAt"`
+}
+
+type sessionListResponse struct {
+	Sessions []sessionInfo `json:"sessions"`
+}
+
+type revokedSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
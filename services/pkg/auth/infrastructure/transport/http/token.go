@@ -0,0 +1,93 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"arch-homework/pkg/auth/app"
+)
+
+const (
+	grantTypePassword     = "password"
+	grantTypeRefreshToken = "refresh_token"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+	tokenTypeBearer     = "Bearer"
+)
+
+var errInvalidGrantType = errors.New("invalid grant type")
+
+// tokenHandler issues tokens for clients not able to keep the session cookie,
+// the access token is sent in the Authorization header instead of the cookie
+func (s *Server) tokenHandler(w http.ResponseWriter, r *http.Request) error {
+	var request tokenRequest
+	bytesBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	_ = r.Body.Close()
+	if err = json.Unmarshal(bytesBody, &request); err != nil {
+		return err
+	}
+
+	var tokens *app.IssuedTokens
+	switch request.GrantType {
+	case grantTypePassword:
+		user, err2 := s.userService.FindUserByLoginAndPassword(request.Login, request.Password)
+		if err2 != nil {
+			return err2
+		}
+		tokens, err = s.tokenService.IssueTokens(user.UserID, getClientInfo(r))
+	case grantTypeRefreshToken:
+		tokens, err = s.tokenService.RefreshTokens(request.RefreshToken, getClientInfo(r))
+	default:
+		return errors.Wrapf(errInvalidGrantType, "%q", request.GrantType)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeResponse(w, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    tokenTypeBearer,
+		ExpiresIn:    int(time.Until(tokens.AccessTokenExpiry).Round(time.Second).Seconds()),
+		RefreshToken: tokens.RefreshToken,
+	})
+	return nil
+}
+
+// getBearerToken returns false when the request has no access token, so the session cookie is checked
+func getBearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get(authorizationHeader)
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)), true
+}
+
+type tokenRequest struct {
+	GrantType    string `json:"grantType"`
+	Login        string `json:"login,omitempty"`
+	Password     string `json:"password,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"accessToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
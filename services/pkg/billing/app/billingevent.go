@@ -0,0 +1,224 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+const typeAccountCreated = "billing.account_created"
+const typeAccountToppedUp = "billing.account_topped_up"
+const typeAccountOverdrawn = "billing.account_overdrawn"
+const typeTransferCompleted = "billing.transfer_completed"
+
+// typePaymentSucceeded is sent for payments captured without authorization by the internal payment api
+const typePaymentSucceeded = "billing.payment_succeeded"
+const typePaymentAuthorized = "billing.payment_authorized"
+const typePaymentFailed = "billing.payment_failed"
+const typePaymentCaptured = "billing.payment_captured"
+const typePaymentVoided = "billing.payment_voided"
+const typeRefundCompleted = "billing.refund_completed"
+
+type PaymentFailureReason string
+
+const (
+	PaymentFailureAccountNotFound  PaymentFailureReason = "account_not_found"
+	PaymentFailureNotEnoughFunds   PaymentFailureReason = "not_enough_funds"
+	PaymentFailureCurrencyMismatch PaymentFailureReason = "currency_mismatch"
+	PaymentFailurePaymentMismatch  PaymentFailureReason = "payment_mismatch"
+	// PaymentFailureNotAuthorized is reported when captured payment was not authorized or has been voided
+	PaymentFailureNotAuthorized PaymentFailureReason = "payment_not_authorized"
+	// PaymentFailureExpired is reported in payment voided event when the hold has expired before capture
+	PaymentFailureExpired PaymentFailureReason = "authorization_expired"
+)
+
+func NewAccountCreatedEvent(account *UserAccount) integrationevent.EventData {
+	body, _ := json.Marshal(accountEventBody{
+		UserID:  string(account.UserID),
+		Balance: account.Amount,
+	})
+
+	return integrationevent.EventData{
+		UID:  newUID(),
+		Type: typeAccountCreated,
+		Body: string(body),
+	}
+}
+
+func NewAccountToppedUpEvent(requestID RequestID, account *UserAccount, amount money.Money) integrationevent.EventData {
+	body, _ := json.Marshal(accountEventBody{
+		UserID:    string(account.UserID),
+		RequestID: string(requestID),
+		Amount:    &amount,
+		Balance:   account.Amount,
+	})
+
+	return integrationevent.EventData{
+		UID:  newUID(),
+		Type: typeAccountToppedUp,
+		Body: string(body),
+	}
+}
+
+// NewAccountOverdrawnEvent is sent when payment makes the account balance negative
+func NewAccountOverdrawnEvent(account *UserAccount) integrationevent.EventData {
+	body, _ := json.Marshal(accountEventBody{
+		UserID:      string(account.UserID),
+		Balance:     account.Amount,
+		CreditLimit: &account.CreditLimit,
+	})
+
+	return integrationevent.EventData{
+		UID:  newUID(),
+		Type: typeAccountOverdrawn,
+		Body: string(body),
+	}
+}
+
+func NewTransferCompletedEvent(transfer *Transfer) integrationevent.EventData {
+	body, _ := json.Marshal(transferCompletedEventBody{
+		RequestID:   string(transfer.RequestID),
+		SenderID:    string(transfer.SenderID),
+		RecipientID: string(transfer.RecipientID),
+		Amount:      transfer.Amount,
+	})
+
+	return integrationevent.EventData{
+		UID:  newUID(),
+		Type: typeTransferCompleted,
+		Body: string(body),
+	}
+}
+
+func NewPaymentSucceededEvent(payment *Payment) integrationevent.EventData {
+	return newPaymentEvent(typePaymentSucceeded, payment.OrderID, payment.UserID, payment.ID, "")
+}
+
+func NewPaymentAuthorizedEvent(orderID OrderID, userID UserID, paymentID PaymentID) integrationevent.EventData {
+	return newPaymentEvent(typePaymentAuthorized, orderID, userID, paymentID, "")
+}
+
+func NewPaymentCapturedEvent(payment *Payment) integrationevent.EventData {
+	return newPaymentEvent(typePaymentCaptured, payment.OrderID, payment.UserID, payment.ID, "")
+}
+
+func NewPaymentVoidedEvent(payment *Payment) integrationevent.EventData {
+	var reason PaymentFailureReason
+	if payment.Status == PaymentStatusExpired {
+		reason = PaymentFailureExpired
+	}
+	return newPaymentEvent(typePaymentVoided, payment.OrderID, payment.UserID, payment.ID, reason)
+}
+
+// NewPaymentFailedEvent paymentID is empty if the payment has not been stored
+func NewPaymentFailedEvent(orderID OrderID, userID UserID, paymentID PaymentID, reason PaymentFailureReason) integrationevent.EventData {
+	return newPaymentEvent(typePaymentFailed, orderID, userID, paymentID, reason)
+}
+
+// newAuthorizationResultEvent reports stored payment as authorized unless it has failed,
+// payment of zero amount is authorized by capturing it immediately
+func newAuthorizationResultEvent(payment *Payment) integrationevent.EventData {
+	if payment.Status == PaymentStatusFailed {
+		return NewPaymentFailedEvent(payment.OrderID, payment.UserID, payment.ID, payment.FailureReason)
+	}
+	return NewPaymentAuthorizedEvent(payment.OrderID, payment.UserID, payment.ID)
+}
+
+// newPaymentResultEvent reports stored payment captured without authorization as succeeded unless it has failed
+func newPaymentResultEvent(payment *Payment) integrationevent.EventData {
+	if payment.Status == PaymentStatusFailed {
+		return NewPaymentFailedEvent(payment.OrderID, payment.UserID, payment.ID, payment.FailureReason)
+	}
+	return NewPaymentSucceededEvent(payment)
+}
+
+func newPaymentEvent(eventType string, orderID OrderID, userID UserID, paymentID PaymentID, reason PaymentFailureReason) integrationevent.EventData {
+	body, _ := json.Marshal(paymentEventBody{
+		OrderID:   string(orderID),
+		UserID:    string(userID),
+		PaymentID: string(paymentID),
+		Reason:    string(reason),
+	})
+
+	return integrationevent.EventData{
+		UID:  newUID(),
+		Type: eventType,
+		Body: string(body),
+	}
+}
+
+// NewRefundCompletedEvent is sent after the refund amount is credited to the user account
+func NewRefundCompletedEvent(refund *Refund, payment *Payment) integrationevent.EventData {
+	body, _ := json.Marshal(refundCompletedEventBody{
+		RefundID:      string(refund.ID),
+		PaymentID:     string(refund.PaymentID),
+		OrderID:       string(refund.OrderID),
+		UserID:        string(refund.UserID),
+		Amount:        refund.Amount,
+		FullyRefunded: payment.Status == PaymentStatusRefunded,
+	})
+
+	return integrationevent.EventData{
+		UID:  newUID(),
+		Type: typeRefundCompleted,
+		Body: string(body),
+	}
+}
+
+func newUID() integrationevent.EventUID {
+	return integrationevent.EventUID(uuid.GenerateNew())
+}
+
+// paymentFailureReason returns reason for expected payment errors
+func paymentFailureReason(err error) (PaymentFailureReason, bool) {
+	switch errors.Cause(err) {
+	case ErrNotEnoughFunds:
+		return PaymentFailureNotEnoughFunds, true
+	case money.ErrCurrencyMismatch:
+		return PaymentFailureCurrencyMismatch, true
+	case ErrUserAccountNotFound:
+		return PaymentFailureAccountNotFound, true
+	case ErrPaymentMismatch:
+		return PaymentFailurePaymentMismatch, true
+	case ErrPaymentNotFound, ErrInvalidPaymentStatus:
+		return PaymentFailureNotAuthorized, true
+	default:
+		return "", false
+	}
+}
+
+// accountEventBody Balance is the account balance after the change
+type accountEventBody struct {
+	UserID      string       `json:"user_id"`
+	RequestID   string       `json:"request_id,omitempty"`
+	Amount      *money.Money `json:"amount,omitempty"`
+	Balance     money.Money  `json:"balance"`
+	CreditLimit *money.Money `json:"credit_limit,omitempty"`
+}
+
+type transferCompletedEventBody struct {
+	RequestID   string      `json:"request_id"`
+	SenderID    string      `json:"sender_id"`
+	RecipientID string      `json:"recipient_id"`
+	Amount      money.Money `json:"amount"`
+}
+
+type paymentEventBody struct {
+	OrderID   string `json:"order_id"`
+	UserID    string `json:"user_id"`
+	PaymentID string `json:"payment_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type refundCompletedEventBody struct {
+	RefundID      string      `json:"refund_id"`
+	PaymentID     string      `json:"payment_id"`
+	OrderID       string      `json:"order_id"`
+	UserID        string      `json:"user_id"`
+	Amount        money.Money `json:"amount"`
+	FullyRefunded bool        `json:"fully_refunded"`
+}
@@ -1,23 +1,64 @@
 package app
 
-func NewBillingQueryService(repoRead UserAccountRepositoryRead) BillingQueryService {
+func NewBillingQueryService(repoRead UserAccountRepositoryRead, ledgerRepoRead LedgerRepositoryRead) BillingQueryService {
 	return &billingQueryService{
-		repoRead: repoRead,
+		repoRead:       repoRead,
+		ledgerRepoRead: ledgerRepoRead,
 	}
 }
 
 type BillingQueryService interface {
-	AccountBalance(userID UserID) (Amount, error)
+	Account(userID UserID) (*UserAccount, error)
+	Transactions(spec LedgerListSpec) (LedgerPage, error)
+	Statement(spec StatementSpec, writer StatementWriter) error
 }
 
 type billingQueryService struct {
-	repoRead UserAccountRepositoryRead
+	repoRead       UserAccountRepositoryRead
+	ledgerRepoRead LedgerRepositoryRead
 }
 
-func (s *billingQueryService) AccountBalance(userID UserID) (Amount, error) {
-	account, err := s.repoRead.FindByID(userID)
+func (s *billingQueryService) Account(userID UserID) (*UserAccount, error) {
+	return s.repoRead.FindByID(userID)
+}
+
+func (s *billingQueryService) Transactions(spec LedgerListSpec) (LedgerPage, error) {
+	if err := spec.validate(); err != nil {
+		return LedgerPage{}, err
+	}
+	transactions, err := s.ledgerRepoRead.FindByUserID(spec)
+	if err != nil {
+		return LedgerPage{}, err
+	}
+	return newLedgerPage(transactions, &spec), nil
+}
+
+// Statement writes opening balance, movements with running balance and closing balance of the period
+func (s *billingQueryService) Statement(spec StatementSpec, writer StatementWriter) error {
+	if err := spec.validate(); err != nil {
+		return err
+	}
+	account, err := s.repoRead.FindByID(spec.UserID)
+	if err != nil {
+		return err
+	}
+	balance, err := s.ledgerRepoRead.BalanceBefore(spec.UserID, account.Currency(), spec.From)
+	if err != nil {
+		return err
+	}
+	if err = writer.WriteOpeningBalance(spec, balance); err != nil {
+		return err
+	}
+	err = s.ledgerRepoRead.ForEachMovement(spec, func(movement StatementMovement) error {
+		var err error
+		if balance, err = balance.Add(movement.Amount); err != nil {
+			return err
+		}
+		movement.Balance = balance
+		return writer.WriteMovement(movement)
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return account.Amount, nil
+	return writer.WriteClosingBalance(balance)
 }
@@ -1,76 +1,663 @@
 package app
 
-import "github.com/pkg/errors"
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/storedevent"
+
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
 
 var ErrNotEnoughFunds = errors.New("not enough funds for payment")
-var ErrAlreadyProcessed = errors.New("request with this id already processed")
 
-func NewBillingService(trUnitFactory TransactionalUnitFactory) BillingService {
-	return &billingService{trUnitFactory: trUnitFactory}
+// ExpirationBatchSize limits number of payments expired in one transaction
+const ExpirationBatchSize = 100
+
+func NewBillingService(
+	trUnitFactory TransactionalUnitFactory,
+	eventSender storedevent.Sender,
+	accountCurrency money.Currency,
+	holdLifetime time.Duration,
+) BillingService {
+	return &billingService{
+		trUnitFactory:   trUnitFactory,
+		eventSender:     eventSender,
+		accountCurrency: accountCurrency,
+		holdLifetime:    holdLifetime,
+	}
 }
 
 type BillingService interface {
 	CreateAccount(userID UserID) error
-	TopUpAccount(requestID RequestID, userID UserID, amount Amount) error
-	ProcessPayment(userID UserID, amount Amount) error
+	TopUpAccount(requestID RequestID, userID UserID, amount money.Money) error
+	TransferFunds(transfer Transfer) error
+	ProcessPayment(orderID OrderID, userID UserID, amount money.Money) (*Payment, error)
+	AuthorizePayment(orderID OrderID, userID UserID, amount money.Money) (*Payment, error)
+	CapturePayment(orderID OrderID) (*Payment, error)
+	VoidPayment(orderID OrderID) (*Payment, error)
+	RefundPayment(requestID RequestID, paymentID PaymentID, amount *money.Money) (*Refund, error)
+	SetCreditLimit(userID UserID, limit money.Money) (*UserAccount, error)
+	AuthorizeOrderPayment(orderID OrderID, userID UserID, amount money.Money) error
+	CaptureOrderPayment(orderID OrderID, userID UserID) error
+	ReleaseOrderPayment(orderID OrderID, userID UserID, amount money.Money) error
+	ExpireAuthorizations(before time.Time) (int, error)
 }
 
 type billingService struct {
-	trUnitFactory TransactionalUnitFactory
+	trUnitFactory   TransactionalUnitFactory
+	eventSender     storedevent.Sender
+	accountCurrency money.Currency
+	holdLifetime    time.Duration
 }
 
 func (s *billingService) CreateAccount(userID UserID) error {
-	return s.executeInTransaction(func(repoProvider RepositoryProvider) error {
+	return s.executeWithEvent(func(repoProvider RepositoryProvider) (integrationevent.EventData, error) {
 		repo := repoProvider.UserAccountRepository()
 		_, err := repo.FindByID(userID)
 		if err == nil {
-			return ErrUserAccountAlreadyExists
+			return integrationevent.EventData{}, ErrUserAccountAlreadyExists
 		}
 		if errors.Cause(err) != ErrUserAccountNotFound {
-			return err
+			return integrationevent.EventData{}, err
 		}
 		userAccount := UserAccount{
-			UserID: userID,
-			Amount: AmountFromRawValue(0),
+			UserID:      userID,
+			Amount:      money.Zero(s.accountCurrency),
+			Held:        money.Zero(s.accountCurrency),
+			CreditLimit: money.Zero(s.accountCurrency),
+		}
+		if err = repo.Store(&userAccount); err != nil {
+			return integrationevent.EventData{}, err
 		}
-		return repo.Store(&userAccount)
+		return NewAccountCreatedEvent(&userAccount), nil
 	})
 }
 
-func (s *billingService) TopUpAccount(requestID RequestID, userID UserID, amount Amount) error {
-	return s.executeInTransaction(func(provider RepositoryProvider) error {
-		eventRepo := provider.ProcessedRequestRepository()
-		alreadyProcessed, err := eventRepo.SetRequestProcessed(requestID)
+func (s *billingService) TopUpAccount(requestID RequestID, userID UserID, amount money.Money) error {
+	return s.executeWithEvent(func(provider RepositoryProvider) (integrationevent.EventData, error) {
+		accountRepo := provider.UserAccountRepository()
+		account, err := accountRepo.FindByIDForUpdate(userID)
 		if err != nil {
+			return integrationevent.EventData{}, err
+		}
+		if err = account.Credit(amount); err != nil {
+			return integrationevent.EventData{}, err
+		}
+		transaction, err := newLedgerTransaction(LedgerTransactionTopUp, userID, amount, LedgerAccountExternal)
+		if err != nil {
+			return integrationevent.EventData{}, err
+		}
+		transaction.RequestID = &requestID
+		if err = storeWithLedgerTransaction(provider, account, transaction); err != nil {
+			return integrationevent.EventData{}, err
+		}
+		return NewAccountToppedUpEvent(requestID, account, amount), nil
+	})
+}
+
+// TransferFunds debits the sender and credits the recipient in one transaction,
+// accounts are locked in the user id order so opposite transfers between the same users can not deadlock
+func (s *billingService) TransferFunds(transfer Transfer) error {
+	if err := transfer.validate(); err != nil {
+		return err
+	}
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		sender, recipient, err := lockTransferAccounts(provider.UserAccountRepository(), &transfer)
+		if err != nil {
+			return err
+		}
+		if err = sender.Debit(transfer.Amount); err != nil {
+			return err
+		}
+		if err = recipient.Credit(transfer.Amount); err != nil {
+			return err
+		}
+		outgoing, incoming, err := newTransferLedgerTransactions(&transfer)
+		if err != nil {
+			return err
+		}
+		if err = storeWithLedgerTransaction(provider, sender, outgoing); err != nil {
+			return err
+		}
+		if err = storeWithLedgerTransaction(provider, recipient, incoming); err != nil {
 			return err
 		}
-		if alreadyProcessed {
-			return ErrAlreadyProcessed
+
+		if err = s.storeEvent(provider, NewTransferCompletedEvent(&transfer)); err != nil {
+			return err
+		}
+		enteredOverdraft, err := sender.enteredOverdraft(transfer.Amount)
+		if err != nil || !enteredOverdraft {
+			return err
 		}
+		return s.storeEvent(provider, NewAccountOverdrawnEvent(sender))
+	})
+	if err != nil {
+		return err
+	}
 
+	s.eventSender.SendStoredEvents()
+	return nil
+}
+
+// ProcessPayment charges the account once per order, repeated call for the same order returns the stored payment
+// or the error it has failed with, the result is published with payment succeeded or payment failed event
+// only when the payment is made, so repeated calls do not publish it again
+func (s *billingService) ProcessPayment(orderID OrderID, userID UserID, amount money.Money) (*Payment, error) {
+	if !amount.IsPositive() {
+		return nil, errors.WithStack(ErrNegativeAmount)
+	}
+	var payment *Payment
+	err := s.executeWithEvent(func(provider RepositoryProvider) (integrationevent.EventData, error) {
+		var created bool
+		var err error
+		payment, created, err = s.processPayment(provider, orderID, userID, amount, nil)
+		if err != nil || !created {
+			return integrationevent.EventData{}, err
+		}
+		return newPaymentResultEvent(payment), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, payment.Err()
+}
+
+// AuthorizePayment holds amount on the account until the payment is captured, voided or expired,
+// repeated call for the same order returns the stored payment or the error it has failed with
+func (s *billingService) AuthorizePayment(orderID OrderID, userID UserID, amount money.Money) (*Payment, error) {
+	if !amount.IsPositive() {
+		return nil, errors.WithStack(ErrNegativeAmount)
+	}
+	var payment *Payment
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		var err error
+		payment, _, err = s.processPayment(provider, orderID, userID, amount, &s.holdLifetime)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payment, payment.Err()
+}
+
+func (s *billingService) CapturePayment(orderID OrderID) (*Payment, error) {
+	var payment *Payment
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		var err error
+		payment, err = s.capturePayment(provider, orderID)
+		return err
+	})
+	return payment, err
+}
+
+func (s *billingService) VoidPayment(orderID OrderID) (*Payment, error) {
+	var payment *Payment
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		var err error
+		payment, err = provider.PaymentRepository().FindByOrderIDForUpdate(orderID)
+		if err != nil {
+			return err
+		}
+		return voidPayment(provider, payment, PaymentStatusVoided)
+	})
+	return payment, err
+}
+
+// RefundPayment returns amount of captured payment to the user account, the whole not refunded amount
+// is returned if amount is nil, repeated call with the same request id returns the stored refund
+func (s *billingService) RefundPayment(requestID RequestID, paymentID PaymentID, amount *money.Money) (*Refund, error) {
+	var refund *Refund
+	err := s.executeWithEvent(func(provider RepositoryProvider) (integrationevent.EventData, error) {
+		payment, err := provider.PaymentRepository().FindByIDForUpdate(paymentID)
+		if err != nil {
+			return integrationevent.EventData{}, err
+		}
+		refund, err = provider.RefundRepository().FindByRequestID(requestID)
+		if err == nil {
+			return integrationevent.EventData{}, refund.checkRepeated(paymentID, amount)
+		}
+		if errors.Cause(err) != ErrRefundNotFound {
+			return integrationevent.EventData{}, err
+		}
+
+		refundAmount, err := payment.NotRefundedAmount()
+		if err != nil {
+			return integrationevent.EventData{}, err
+		}
+		if amount != nil {
+			refundAmount = *amount
+		}
+		refund, err = refundPayment(provider, payment, refundAmount, &requestID)
+		if err != nil {
+			return integrationevent.EventData{}, err
+		}
+		return NewRefundCompletedEvent(refund, payment), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (s *billingService) SetCreditLimit(userID UserID, limit money.Money) (*UserAccount, error) {
+	var account *UserAccount
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
 		accountRepo := provider.UserAccountRepository()
-		account, err := accountRepo.FindByID(userID)
+		var err error
+		account, err = accountRepo.FindByIDForUpdate(userID)
 		if err != nil {
 			return err
 		}
-		account.Amount = AmountFromRawValue(account.Amount.RawValue() + amount.RawValue())
+		if err = account.SetCreditLimit(limit); err != nil {
+			return err
+		}
 		return accountRepo.Store(account)
 	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
 }
 
-func (s *billingService) ProcessPayment(userID UserID, amount Amount) error {
-	return s.executeInTransaction(func(provider RepositoryProvider) error {
-		repo := provider.UserAccountRepository()
-		account, err := repo.FindByID(userID)
+// AuthorizeOrderPayment holds the order price and publishes the authorization result,
+// insufficient funds and currency mismatch are reported with payment failed event instead of an error,
+// order fully covered by discount is paid without hold
+func (s *billingService) AuthorizeOrderPayment(orderID OrderID, userID UserID, amount money.Money) error {
+	return s.executeWithEvent(func(provider RepositoryProvider) (integrationevent.EventData, error) {
+		payment, _, err := s.processPayment(provider, orderID, userID, amount, &s.holdLifetime)
 		if err != nil {
+			return failedPaymentEvent(orderID, userID, err)
+		}
+		return newAuthorizationResultEvent(payment), nil
+	})
+}
+
+// CaptureOrderPayment debits held amount of the confirmed order,
+// payment which can not be captured is reported with payment failed event and its hold is released,
+// as the order gets rejected without asking to release the payment it has failed,
+// orders paid before payments were stored have been already debited
+func (s *billingService) CaptureOrderPayment(orderID OrderID, userID UserID) error {
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		event := integrationevent.EventData{}
+		payment, err := s.capturePayment(provider, orderID)
+		switch {
+		case err == nil:
+			event = NewPaymentCapturedEvent(payment)
+		case errors.Cause(err) == ErrPaymentNotFound:
+			return nil
+		default:
+			if event, err = failedPaymentEvent(orderID, userID, err); err != nil {
+				return err
+			}
+			if err = s.releaseFailedCapture(provider, orderID); err != nil {
+				return err
+			}
+		}
+		return s.storeEvent(provider, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.eventSender.SendStoredEvents()
+	return nil
+}
+
+// releaseFailedCapture voids the payment still authorized after its capture has failed,
+// e.g. when the credit limit has been lowered since the authorization
+func (s *billingService) releaseFailedCapture(provider RepositoryProvider, orderID OrderID) error {
+	payment, err := provider.PaymentRepository().FindByOrderIDForUpdate(orderID)
+	if err != nil {
+		return err
+	}
+	if payment.Status != PaymentStatusAuthorized {
+		return nil
+	}
+	if err = voidPayment(provider, payment, PaymentStatusVoided); err != nil {
+		return err
+	}
+	return s.storeEvent(provider, NewPaymentVoidedEvent(payment))
+}
+
+// ReleaseOrderPayment voids held amount or refunds not refunded amount of captured payment of rejected or cancelled order,
+// orders paid before payments were stored are refunded with the order price
+func (s *billingService) ReleaseOrderPayment(orderID OrderID, userID UserID, amount money.Money) error {
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		payment, err := provider.PaymentRepository().FindByOrderIDForUpdate(orderID)
+		if err != nil {
+			if errors.Cause(err) == ErrPaymentNotFound {
+				return refundOrder(provider, orderID, userID, amount)
+			}
 			return err
 		}
-		if amount.RawValue() > account.Amount.RawValue() {
-			return ErrNotEnoughFunds
+
+		switch payment.Status {
+		case PaymentStatusAuthorized:
+			if err = voidPayment(provider, payment, PaymentStatusVoided); err != nil {
+				return err
+			}
+			return s.storeEvent(provider, NewPaymentVoidedEvent(payment))
+		case PaymentStatusCaptured:
+			notRefundedAmount, err := payment.NotRefundedAmount()
+			if err != nil {
+				return err
+			}
+			if notRefundedAmount.IsZero() {
+				// payment fully covered by discount has nothing to refund
+				payment.Status = PaymentStatusRefunded
+				return provider.PaymentRepository().Store(payment)
+			}
+			refund, err := refundPayment(provider, payment, notRefundedAmount, nil)
+			if err != nil {
+				return err
+			}
+			return s.storeEvent(provider, NewRefundCompletedEvent(refund, payment))
+		default:
+			return nil
 		}
-		account.Amount = AmountFromRawValue(account.Amount.RawValue() - amount.RawValue())
-		return repo.Store(account)
 	})
+	if err != nil {
+		return err
+	}
+
+	s.eventSender.SendStoredEvents()
+	return nil
+}
+
+// ExpireAuthorizations releases holds of payments expired before the time, returns number of expired payments,
+// payments are expired in batches so the caller should repeat while the whole batch is expired
+func (s *billingService) ExpireAuthorizations(before time.Time) (int, error) {
+	var expired int
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		payments, err := provider.PaymentRepository().FindExpiredForUpdate(before, ExpirationBatchSize)
+		if err != nil {
+			return err
+		}
+		// accounts are locked in the same order by concurrent expiration runs to avoid deadlocks
+		sort.Slice(payments, func(i, j int) bool {
+			return payments[i].UserID < payments[j].UserID
+		})
+		for i := range payments {
+			payment := &payments[i]
+			if err = voidPayment(provider, payment, PaymentStatusExpired); err != nil {
+				return err
+			}
+			if err = s.storeEvent(provider, NewPaymentVoidedEvent(payment)); err != nil {
+				return err
+			}
+		}
+		expired = len(payments)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.eventSender.SendStoredEvents()
+	return expired, nil
+}
+
+func lockTransferAccounts(repo UserAccountRepository, transfer *Transfer) (sender *UserAccount, recipient *UserAccount, err error) {
+	lockSender := func() error {
+		sender, err = repo.FindByIDForUpdate(transfer.SenderID)
+		return err
+	}
+	lockRecipient := func() error {
+		recipient, err = repo.FindByIDForUpdate(transfer.RecipientID)
+		if errors.Cause(err) == ErrUserAccountNotFound {
+			return errors.Wrapf(ErrRecipientAccountNotFound, "user %s", string(transfer.RecipientID))
+		}
+		return err
+	}
+
+	locks := []func() error{lockSender, lockRecipient}
+	if transfer.RecipientID < transfer.SenderID {
+		locks = []func() error{lockRecipient, lockSender}
+	}
+	for _, lock := range locks {
+		if err = lock(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return sender, recipient, nil
+}
+
+// processPayment returns stored payment of the order or makes the new one, created reports the new payment,
+// the new payment is authorized for holdLifetime if it is set or captured immediately otherwise,
+// expected payment failures are stored with the payment, error is returned only if payment should be retried
+func (s *billingService) processPayment(
+	provider RepositoryProvider,
+	orderID OrderID,
+	userID UserID,
+	amount money.Money,
+	holdLifetime *time.Duration,
+) (payment *Payment, created bool, err error) {
+	paymentRepo := provider.PaymentRepository()
+	payment, err = paymentRepo.FindByOrderID(orderID)
+	if err == nil {
+		return payment, false, payment.checkRepeated(userID, amount)
+	}
+	if errors.Cause(err) != ErrPaymentNotFound {
+		return nil, false, err
+	}
+
+	payment = newPayment(orderID, userID, amount)
+	if !amount.IsZero() {
+		if holdLifetime != nil {
+			err = holdPayment(provider, payment, *holdLifetime)
+		} else {
+			err = s.debitPayment(provider, payment)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return payment, true, paymentRepo.Store(payment)
+}
+
+func holdPayment(provider RepositoryProvider, payment *Payment, lifetime time.Duration) error {
+	accountRepo := provider.UserAccountRepository()
+	account, err := accountRepo.FindByIDForUpdate(payment.UserID)
+	if err == nil {
+		err = account.Hold(payment.Amount)
+	}
+	if err != nil {
+		reason, ok := paymentFailureReason(err)
+		if !ok {
+			return err
+		}
+		payment.fail(reason)
+		return nil
+	}
+	payment.authorize(lifetime)
+	return accountRepo.Store(account)
+}
+
+func (s *billingService) debitPayment(provider RepositoryProvider, payment *Payment) error {
+	account, err := provider.UserAccountRepository().FindByIDForUpdate(payment.UserID)
+	if err == nil {
+		err = account.Debit(payment.Amount)
+	}
+	if err != nil {
+		reason, ok := paymentFailureReason(err)
+		if !ok {
+			return err
+		}
+		payment.fail(reason)
+		return nil
+	}
+	return s.storePaymentLedgerTransaction(provider, account, payment)
+}
+
+// capturePayment debits held amount, payment expired before capture is debited if there are enough available funds
+func (s *billingService) capturePayment(provider RepositoryProvider, orderID OrderID) (*Payment, error) {
+	paymentRepo := provider.PaymentRepository()
+	payment, err := paymentRepo.FindByOrderIDForUpdate(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status == PaymentStatusCaptured {
+		return payment, nil
+	}
+	if payment.Status != PaymentStatusAuthorized && payment.Status != PaymentStatusExpired {
+		return nil, errors.Wrapf(ErrInvalidPaymentStatus, "payment %s", string(payment.ID))
+	}
+
+	account, err := provider.UserAccountRepository().FindByIDForUpdate(payment.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status == PaymentStatusAuthorized {
+		err = account.CaptureHold(payment.Amount)
+	} else {
+		err = account.Debit(payment.Amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = payment.changeStatus(PaymentStatusCaptured, PaymentStatusAuthorized, PaymentStatusExpired); err != nil {
+		return nil, err
+	}
+	payment.ExpirationDate = nil
+	if err = s.storePaymentLedgerTransaction(provider, account, payment); err != nil {
+		return nil, err
+	}
+	return payment, paymentRepo.Store(payment)
+}
+
+// voidPayment releases the hold of authorized payment, already voided or expired payment is left as is
+func voidPayment(provider RepositoryProvider, payment *Payment, status PaymentStatus) error {
+	if payment.Status == PaymentStatusVoided || payment.Status == PaymentStatusExpired {
+		return nil
+	}
+	if err := payment.changeStatus(status, PaymentStatusAuthorized); err != nil {
+		return err
+	}
+
+	accountRepo := provider.UserAccountRepository()
+	account, err := accountRepo.FindByIDForUpdate(payment.UserID)
+	if err != nil {
+		return err
+	}
+	if err = account.ReleaseHold(payment.Amount); err != nil {
+		return err
+	}
+	if err = accountRepo.Store(account); err != nil {
+		return err
+	}
+	return provider.PaymentRepository().Store(payment)
+}
+
+// refundPayment credits the user account with amount of the payment and stores the refund
+func refundPayment(provider RepositoryProvider, payment *Payment, amount money.Money, requestID *RequestID) (*Refund, error) {
+	if err := payment.refund(amount); err != nil {
+		return nil, err
+	}
+	account, err := provider.UserAccountRepository().FindByIDForUpdate(payment.UserID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "refund of payment %s", string(payment.ID))
+	}
+	if err = account.Credit(amount); err != nil {
+		return nil, errors.Wrapf(err, "refund of payment %s", string(payment.ID))
+	}
+	transaction, err := newLedgerTransaction(LedgerTransactionRefund, payment.UserID, amount, LedgerAccountRevenue)
+	if err != nil {
+		return nil, err
+	}
+	transaction.OrderID = &payment.OrderID
+	transaction.RequestID = requestID
+	if err = storeWithLedgerTransaction(provider, account, transaction); err != nil {
+		return nil, err
+	}
+	if err = provider.PaymentRepository().Store(payment); err != nil {
+		return nil, err
+	}
+
+	refund := newRefund(payment, amount, requestID)
+	return refund, provider.RefundRepository().Add(refund)
+}
+
+func refundOrder(provider RepositoryProvider, orderID OrderID, userID UserID, amount money.Money) error {
+	if amount.IsZero() {
+		return nil
+	}
+	account, err := provider.UserAccountRepository().FindByIDForUpdate(userID)
+	if err != nil {
+		return errors.Wrapf(err, "refund for order %s", string(orderID))
+	}
+	if err = account.Credit(amount); err != nil {
+		return errors.Wrapf(err, "refund for order %s", string(orderID))
+	}
+	transaction, err := newLedgerTransaction(LedgerTransactionRefund, userID, amount, LedgerAccountRevenue)
+	if err != nil {
+		return err
+	}
+	transaction.OrderID = &orderID
+	return storeWithLedgerTransaction(provider, account, transaction)
+}
+
+// failedPaymentEvent reports expected payment error with payment failed event, other errors are returned to retry
+func failedPaymentEvent(orderID OrderID, userID UserID, err error) (integrationevent.EventData, error) {
+	reason, ok := paymentFailureReason(err)
+	if !ok {
+		return integrationevent.EventData{}, err
+	}
+	return NewPaymentFailedEvent(orderID, userID, "", reason), nil
+}
+
+// storePaymentLedgerTransaction stores debited account, account entering overdraft is reported with account overdrawn event
+func (s *billingService) storePaymentLedgerTransaction(provider RepositoryProvider, account *UserAccount, payment *Payment) error {
+	transaction, err := newPaymentLedgerTransaction(payment.UserID, payment.Amount)
+	if err != nil {
+		return err
+	}
+	transaction.OrderID = &payment.OrderID
+	if err = storeWithLedgerTransaction(provider, account, transaction); err != nil {
+		return err
+	}
+	enteredOverdraft, err := account.enteredOverdraft(payment.Amount)
+	if err != nil || !enteredOverdraft {
+		return err
+	}
+	return s.storeEvent(provider, NewAccountOverdrawnEvent(account))
+}
+
+func newPaymentLedgerTransaction(userID UserID, amount money.Money) (*LedgerTransaction, error) {
+	userAmount, err := money.Zero(amount.Currency()).Sub(amount)
+	if err != nil {
+		return nil, err
+	}
+	return newLedgerTransaction(LedgerTransactionPayment, userID, userAmount, LedgerAccountRevenue)
+}
+
+// executeWithEvent stores the event returned by f in the same transaction and sends it after commit,
+// f returns empty event when nothing has changed
+func (s *billingService) executeWithEvent(f func(RepositoryProvider) (integrationevent.EventData, error)) error {
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		event, err := f(provider)
+		if err != nil || event.UID == "" {
+			return err
+		}
+		return s.storeEvent(provider, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.eventSender.SendStoredEvents()
+	return nil
+}
+
+func (s *billingService) storeEvent(provider RepositoryProvider, event integrationevent.EventData) error {
+	if err := provider.EventStore().Add(event); err != nil {
+		return err
+	}
+	s.eventSender.EventStored(event.UID)
+	return nil
 }
 
 func (s *billingService) executeInTransaction(f func(RepositoryProvider) error) (err error) {
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/storedevent"
+	"arch-homework/pkg/common/app/uuid"
+
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestProcessPaymentRepeatPublishesNoEvent(t *testing.T) {
+	testCases := []struct {
+		name string
+		// authorize makes the first payment of the order as authorization instead of ProcessPayment
+		authorize      bool
+		balance        int64
+		expectedStatus PaymentStatus
+		expectedErr    error
+		expectedEvents []string
+	}{
+		{
+			name:           "captured payment",
+			balance:        10000,
+			expectedStatus: PaymentStatusCaptured,
+			expectedEvents: []string{typePaymentSucceeded},
+		},
+		{
+			name:           "failed payment",
+			balance:        500,
+			expectedStatus: PaymentStatusFailed,
+			expectedErr:    ErrNotEnoughFunds,
+			expectedEvents: []string{typePaymentFailed},
+		},
+		{
+			name:           "authorized payment",
+			authorize:      true,
+			balance:        10000,
+			expectedStatus: PaymentStatusAuthorized,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			currency := testCurrency(t)
+			userID := UserID(uuid.GenerateNew())
+			trUnit := newFakeTransactionalUnit(&UserAccount{
+				UserID:      userID,
+				Amount:      money.New(testCase.balance, currency),
+				Held:        money.Zero(currency),
+				CreditLimit: money.Zero(currency),
+			})
+			service := NewBillingService(trUnit, fakeEventSender{}, currency, time.Hour)
+			orderID := OrderID(uuid.GenerateNew())
+			amount := money.New(1000, currency)
+
+			var first *Payment
+			var err error
+			if testCase.authorize {
+				first, err = service.AuthorizePayment(orderID, userID, amount)
+			} else {
+				first, err = service.ProcessPayment(orderID, userID, amount)
+			}
+			if errors.Cause(err) != testCase.expectedErr {
+				t.Fatalf("expected first call error %v, got %v", testCase.expectedErr, err)
+			}
+			balance := *trUnit.accounts[userID]
+
+			for i := 0; i < 2; i++ {
+				repeated, err := service.ProcessPayment(orderID, userID, amount)
+				if errors.Cause(err) != testCase.expectedErr {
+					t.Fatalf("expected repeated call error %v, got %v", testCase.expectedErr, err)
+				}
+				if repeated.ID != first.ID || repeated.Status != testCase.expectedStatus {
+					t.Errorf("expected stored payment %s with status %d, got %s with status %d", first.ID, testCase.expectedStatus, repeated.ID, repeated.Status)
+				}
+			}
+
+			if account := *trUnit.accounts[userID]; account != balance {
+				t.Errorf("expected balance %s held %s after repeats, got %s held %s", balance.Amount, balance.Held, account.Amount, account.Held)
+			}
+			if len(trUnit.events) != len(testCase.expectedEvents) {
+				t.Fatalf("expected events %v, got %d events", testCase.expectedEvents, len(trUnit.events))
+			}
+			for i, eventType := range testCase.expectedEvents {
+				if trUnit.events[i].Type != eventType {
+					t.Errorf("expected event %s, got %s", eventType, trUnit.events[i].Type)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessPaymentRejectsReuseWithAnotherAmount(t *testing.T) {
+	currency := testCurrency(t)
+	userID := UserID(uuid.GenerateNew())
+	trUnit := newFakeTransactionalUnit(&UserAccount{
+		UserID:      userID,
+		Amount:      money.New(10000, currency),
+		Held:        money.Zero(currency),
+		CreditLimit: money.Zero(currency),
+	})
+	service := NewBillingService(trUnit, fakeEventSender{}, currency, time.Hour)
+	orderID := OrderID(uuid.GenerateNew())
+
+	if _, err := service.ProcessPayment(orderID, userID, money.New(1000, currency)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := service.ProcessPayment(orderID, userID, money.New(2000, currency))
+	if errors.Cause(err) != ErrPaymentMismatch {
+		t.Errorf("expected error %v, got %v", ErrPaymentMismatch, err)
+	}
+	if len(trUnit.events) != 1 {
+		t.Errorf("expected 1 event, got %d", len(trUnit.events))
+	}
+}
+
+func TestCaptureOrderPayment(t *testing.T) {
+	testCases := []struct {
+		name string
+		// creditLimitBeforeCapture replaces the limit the payment was authorized with
+		creditLimitBeforeCapture int64
+		expectedStatus           PaymentStatus
+		expectedBalance          int64
+		expectedEvents           []string
+	}{
+		{
+			name:                     "captured payment",
+			creditLimitBeforeCapture: 5000,
+			expectedStatus:           PaymentStatusCaptured,
+			expectedBalance:          -2000,
+			expectedEvents:           []string{typePaymentAuthorized, typeAccountOverdrawn, typePaymentCaptured},
+		},
+		{
+			name:            "failed capture releases hold",
+			expectedStatus:  PaymentStatusVoided,
+			expectedBalance: 10000,
+			expectedEvents:  []string{typePaymentAuthorized, typePaymentVoided, typePaymentFailed},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			currency := testCurrency(t)
+			userID := UserID(uuid.GenerateNew())
+			trUnit := newFakeTransactionalUnit(&UserAccount{
+				UserID:      userID,
+				Amount:      money.New(10000, currency),
+				Held:        money.Zero(currency),
+				CreditLimit: money.New(5000, currency),
+			})
+			service := NewBillingService(trUnit, fakeEventSender{}, currency, time.Hour)
+			orderID := OrderID(uuid.GenerateNew())
+
+			if err := service.AuthorizeOrderPayment(orderID, userID, money.New(12000, currency)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := service.SetCreditLimit(userID, money.New(testCase.creditLimitBeforeCapture, currency)); err != nil {
+				t.Fatal(err)
+			}
+			if err := service.CaptureOrderPayment(orderID, userID); err != nil {
+				t.Fatal(err)
+			}
+
+			if payment := trUnit.payments[orderID]; payment.Status != testCase.expectedStatus {
+				t.Errorf("expected payment status %d, got %d", testCase.expectedStatus, payment.Status)
+			}
+			account := trUnit.accounts[userID]
+			if account.Amount != money.New(testCase.expectedBalance, currency) || !account.Held.IsZero() {
+				t.Errorf("expected balance %d with nothing held, got %s held %s", testCase.expectedBalance, account.Amount, account.Held)
+			}
+			if len(trUnit.events) != len(testCase.expectedEvents) {
+				t.Fatalf("expected events %v, got %d events", testCase.expectedEvents, len(trUnit.events))
+			}
+			for i, eventType := range testCase.expectedEvents {
+				if trUnit.events[i].Type != eventType {
+					t.Errorf("expected event %s, got %s", eventType, trUnit.events[i].Type)
+				}
+			}
+		})
+	}
+}
+
+func testCurrency(t *testing.T) money.Currency {
+	currency, err := money.ParseCurrency("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return currency
+}
+
+// fakeTransactionalUnit keeps state in memory, changes are not rolled back on error,
+// repositories not used by the tests are nil
+type fakeTransactionalUnit struct {
+	accounts map[UserID]*UserAccount
+	payments map[OrderID]*Payment
+	events   []integrationevent.EventData
+}
+
+func newFakeTransactionalUnit(accounts ...*UserAccount) *fakeTransactionalUnit {
+	trUnit := &fakeTransactionalUnit{
+		accounts: map[UserID]*UserAccount{},
+		payments: map[OrderID]*Payment{},
+	}
+	for _, account := range accounts {
+		trUnit.accounts[account.UserID] = account
+	}
+	return trUnit
+}
+
+func (u *fakeTransactionalUnit) NewTransactionalUnit() (TransactionalUnit, error) {
+	return u, nil
+}
+
+func (u *fakeTransactionalUnit) Complete(err error) error {
+	return err
+}
+
+func (u *fakeTransactionalUnit) UserAccountRepository() UserAccountRepository {
+	return fakeUserAccountRepository{u}
+}
+
+func (u *fakeTransactionalUnit) LedgerRepository() LedgerRepository {
+	return fakeLedgerRepository{}
+}
+
+func (u *fakeTransactionalUnit) PaymentRepository() PaymentRepository {
+	return fakePaymentRepository{u}
+}
+
+func (u *fakeTransactionalUnit) RefundRepository() RefundRepository {
+	return nil
+}
+
+func (u *fakeTransactionalUnit) ProcessedEventRepository() ProcessedEventRepository {
+	return nil
+}
+
+func (u *fakeTransactionalUnit) EventStore() storedevent.EventStore {
+	return fakeEventStore{u}
+}
+
+type fakeUserAccountRepository struct {
+	unit *fakeTransactionalUnit
+}
+
+func (r fakeUserAccountRepository) FindByID(id UserID) (*UserAccount, error) {
+	account, ok := r.unit.accounts[id]
+	if !ok {
+		return nil, errors.WithStack(ErrUserAccountNotFound)
+	}
+	accountCopy := *account
+	return &accountCopy, nil
+}
+
+func (r fakeUserAccountRepository) FindByIDForUpdate(id UserID) (*UserAccount, error) {
+	return r.FindByID(id)
+}
+
+func (r fakeUserAccountRepository) Store(account *UserAccount) error {
+	accountCopy := *account
+	r.unit.accounts[account.UserID] = &accountCopy
+	return nil
+}
+
+type fakeLedgerRepository struct{}
+
+func (r fakeLedgerRepository) Add(*LedgerTransaction) error {
+	return nil
+}
+
+type fakePaymentRepository struct {
+	unit *fakeTransactionalUnit
+}
+
+func (r fakePaymentRepository) FindByOrderID(orderID OrderID) (*Payment, error) {
+	payment, ok := r.unit.payments[orderID]
+	if !ok {
+		return nil, errors.WithStack(ErrPaymentNotFound)
+	}
+	paymentCopy := *payment
+	return &paymentCopy, nil
+}
+
+func (r fakePaymentRepository) FindByOrderIDForUpdate(orderID OrderID) (*Payment, error) {
+	return r.FindByOrderID(orderID)
+}
+
+func (r fakePaymentRepository) FindByIDForUpdate(id PaymentID) (*Payment, error) {
+	for _, payment := range r.unit.payments {
+		if payment.ID == id {
+			paymentCopy := *payment
+			return &paymentCopy, nil
+		}
+	}
+	return nil, errors.WithStack(ErrPaymentNotFound)
+}
+
+func (r fakePaymentRepository) FindExpiredForUpdate(time.Time, int) ([]Payment, error) {
+	return nil, nil
+}
+
+func (r fakePaymentRepository) Store(payment *Payment) error {
+	paymentCopy := *payment
+	r.unit.payments[payment.OrderID] = &paymentCopy
+	return nil
+}
+
+type fakeEventStore struct {
+	unit *fakeTransactionalUnit
+}
+
+func (s fakeEventStore) Add(event integrationevent.EventData) error {
+	s.unit.events = append(s.unit.events, event)
+	return nil
+}
+
+func (s fakeEventStore) ConfirmDelivery(storedevent.EventID) error {
+	return nil
+}
+
+func (s fakeEventStore) FindByUIDs([]integrationevent.EventUID) ([]storedevent.Event, error) {
+	return nil, nil
+}
+
+func (s fakeEventStore) FindAllUnconfirmedBefore(time.Time) ([]storedevent.Event, error) {
+	return nil, nil
+}
+
+type fakeEventSender struct{}
+
+func (fakeEventSender) EventStored(integrationevent.EventUID) {}
+
+func (fakeEventSender) SendStoredEvents() {}
@@ -1,6 +1,12 @@
 package app
 
-import "arch-homework/pkg/common/app/integrationevent"
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+)
+
+type OrderID uuid.UUID
 
 type ProcessedEventRepository interface {
 	SetEventProcessed(uid integrationevent.EventUID) (alreadyProcessed bool, err error)
@@ -26,3 +32,59 @@ func (e userRegisteredEvent) UserID() UserID {
 func (e userRegisteredEvent) Login() string {
 	return e.login
 }
+
+func NewOrderCreatedEvent(userID UserID, orderID OrderID, price money.Money) UserEvent {
+	return orderCreatedEvent{userID: userID, orderID: orderID, price: price}
+}
+
+type orderCreatedEvent struct {
+	userID  UserID
+	orderID OrderID
+	price   money.Money
+}
+
+func (e orderCreatedEvent) UserID() UserID {
+	return e.userID
+}
+
+func NewOrderConfirmedEvent(userID UserID, orderID OrderID) UserEvent {
+	return orderConfirmedEvent{userID: userID, orderID: orderID}
+}
+
+type orderConfirmedEvent struct {
+	userID  UserID
+	orderID OrderID
+}
+
+func (e orderConfirmedEvent) UserID() UserID {
+	return e.userID
+}
+
+// NewOrderRejectedEvent is created only for order rejected after successful payment authorization
+func NewOrderRejectedEvent(userID UserID, orderID OrderID, price money.Money) UserEvent {
+	return orderRejectedEvent{userID: userID, orderID: orderID, price: price}
+}
+
+type orderRejectedEvent struct {
+	userID  UserID
+	orderID OrderID
+	price   money.Money
+}
+
+func (e orderRejectedEvent) UserID() UserID {
+	return e.userID
+}
+
+func NewOrderCancelledEvent(userID UserID, orderID OrderID, price money.Money) UserEvent {
+	return orderCancelledEvent{userID: userID, orderID: orderID, price: price}
+}
+
+type orderCancelledEvent struct {
+	userID  UserID
+	orderID OrderID
+	price   money.Money
+}
+
+func (e orderCancelledEvent) UserID() UserID {
+	return e.userID
+}
@@ -2,22 +2,38 @@ package app
 
 import (
 	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/storedevent"
+
+	"time"
 )
 
 type IntegrationEventParser interface {
 	ParseIntegrationEvent(event integrationevent.EventData) (UserEvent, error)
 }
 
-func NewEventHandler(trUnitFactory TransactionalUnitFactory, parser IntegrationEventParser) integrationevent.EventHandler {
+func NewEventHandler(
+	trUnitFactory TransactionalUnitFactory,
+	eventSender storedevent.Sender,
+	parser IntegrationEventParser,
+	accountCurrency money.Currency,
+	holdLifetime time.Duration,
+) integrationevent.EventHandler {
 	return &eventHandler{
-		trUnitFactory: trUnitFactory,
-		parser:        parser,
+		trUnitFactory:   trUnitFactory,
+		eventSender:     eventSender,
+		parser:          parser,
+		accountCurrency: accountCurrency,
+		holdLifetime:    holdLifetime,
 	}
 }
 
 type eventHandler struct {
-	trUnitFactory TransactionalUnitFactory
-	parser        IntegrationEventParser
+	trUnitFactory   TransactionalUnitFactory
+	eventSender     storedevent.Sender
+	parser          IntegrationEventParser
+	accountCurrency money.Currency
+	holdLifetime    time.Duration
 }
 
 func (handler *eventHandler) Handle(event integrationevent.EventData) error {
@@ -26,7 +42,7 @@ func (handler *eventHandler) Handle(event integrationevent.EventData) error {
 		return err
 	}
 
-	return handler.executeInTransaction(func(trUnit TransactionalUnit) error {
+	err = handler.executeInTransaction(func(trUnit TransactionalUnit) error {
 		eventRepo := trUnit.ProcessedEventRepository()
 		alreadyProcessed, err := eventRepo.SetEventProcessed(event.UID)
 		if err != nil {
@@ -36,13 +52,28 @@ func (handler *eventHandler) Handle(event integrationevent.EventData) error {
 			return nil
 		}
 
+		service := NewBillingService(trUnit, nestedEventSender{Sender: handler.eventSender}, handler.accountCurrency, handler.holdLifetime)
 		switch e := parsedEvent.(type) {
 		case userRegisteredEvent:
-			return handleUserRegisteredEvent(trUnit, e)
+			return service.CreateAccount(e.UserID())
+		case orderCreatedEvent:
+			return service.AuthorizeOrderPayment(e.orderID, e.UserID(), e.price)
+		case orderConfirmedEvent:
+			return service.CaptureOrderPayment(e.orderID, e.UserID())
+		case orderRejectedEvent:
+			return service.ReleaseOrderPayment(e.orderID, e.UserID(), e.price)
+		case orderCancelledEvent:
+			return service.ReleaseOrderPayment(e.orderID, e.UserID(), e.price)
 		default:
 			return nil
 		}
 	})
+	if err != nil {
+		return err
+	}
+
+	handler.eventSender.SendStoredEvents()
+	return nil
 }
 
 func (handler *eventHandler) executeInTransaction(f func(TransactionalUnit) error) (err error) {
@@ -58,7 +89,10 @@ func (handler *eventHandler) executeInTransaction(f func(TransactionalUnit) erro
 	return err
 }
 
-func handleUserRegisteredEvent(trUnit TransactionalUnit, e userRegisteredEvent) error {
-	service := NewBillingService(trUnit)
-	return service.CreateAccount(e.UserID())
+// nestedEventSender postpones sending until the outer transaction is committed
+type nestedEventSender struct {
+	storedevent.Sender
+}
+
+func (s nestedEventSender) SendStoredEvents() {
 }
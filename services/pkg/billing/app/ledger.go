@@ -0,0 +1,175 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnbalancedLedgerTransaction = errors.New("ledger transaction entries are not balanced")
+
+type LedgerTransactionID uuid.UUID
+
+type LedgerTransactionType string
+
+const (
+	LedgerTransactionTopUp      LedgerTransactionType = "top_up"
+	LedgerTransactionPayment    LedgerTransactionType = "payment"
+	LedgerTransactionRefund     LedgerTransactionType = "refund"
+	LedgerTransactionAdjustment LedgerTransactionType = "adjustment"
+	LedgerTransactionTransfer   LedgerTransactionType = "transfer"
+)
+
+// LedgerAccount is either user account or one of the system accounts
+type LedgerAccount string
+
+const (
+	// LedgerAccountExternal is the source of money coming from outside the system, e.g. top ups
+	LedgerAccountExternal LedgerAccount = "external"
+	// LedgerAccountRevenue receives order payments and pays refunds
+	LedgerAccountRevenue LedgerAccount = "revenue"
+	// LedgerAccountAdjustment balances manual corrections and opening balances
+	LedgerAccountAdjustment LedgerAccount = "adjustment"
+	// LedgerAccountTransfer is the clearing account of transfers between users, both sides of a transfer net to zero on it
+	LedgerAccountTransfer LedgerAccount = "transfer"
+)
+
+func UserLedgerAccount(userID UserID) LedgerAccount {
+	return LedgerAccount("user:" + string(userID))
+}
+
+// LedgerEntry is an immutable change of the account balance, positive amount increases the balance
+type LedgerEntry struct {
+	Account LedgerAccount
+	Amount  money.Money
+}
+
+// LedgerTransaction groups entries which sum up to zero, so money is never created or lost
+type LedgerTransaction struct {
+	ID           LedgerTransactionID
+	Type         LedgerTransactionType
+	UserID       UserID
+	RequestID    *RequestID
+	OrderID      *OrderID
+	Entries      []LedgerEntry
+	CreationDate time.Time
+}
+
+// newLedgerTransaction moves userAmount between the user account and the counterparty system account,
+// negative userAmount decreases user balance
+func newLedgerTransaction(transactionType LedgerTransactionType, userID UserID, userAmount money.Money, counterparty LedgerAccount) (*LedgerTransaction, error) {
+	counterpartyAmount, err := money.Zero(userAmount.Currency()).Sub(userAmount)
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerTransaction{
+		ID:     LedgerTransactionID(uuid.GenerateNew()),
+		Type:   transactionType,
+		UserID: userID,
+		Entries: []LedgerEntry{
+			{Account: UserLedgerAccount(userID), Amount: userAmount},
+			{Account: counterparty, Amount: counterpartyAmount},
+		},
+		CreationDate: time.Now(),
+	}, nil
+}
+
+// UserAmount returns signed change of the user balance made by the transaction
+func (t *LedgerTransaction) UserAmount() (money.Money, error) {
+	account := UserLedgerAccount(t.UserID)
+	for _, entry := range t.Entries {
+		if entry.Account == account {
+			return entry.Amount, nil
+		}
+	}
+	return money.Money{}, errors.Errorf("ledger transaction %s has no user entry", string(t.ID))
+}
+
+func (t *LedgerTransaction) validate() error {
+	if len(t.Entries) < 2 {
+		return errors.WithStack(ErrUnbalancedLedgerTransaction)
+	}
+	sum := money.Zero(t.Entries[0].Amount.Currency())
+	for _, entry := range t.Entries {
+		var err error
+		if sum, err = sum.Add(entry.Amount); err != nil {
+			return errors.Wrap(ErrUnbalancedLedgerTransaction, err.Error())
+		}
+	}
+	if !sum.IsZero() {
+		return errors.Wrapf(ErrUnbalancedLedgerTransaction, "entries sum is %s", sum)
+	}
+	return nil
+}
+
+const DefaultLedgerPageSize = 20
+const MaxLedgerPageSize = 100
+
+var ErrInvalidLedgerListSpec = errors.New("invalid transaction list parameters")
+
+// LedgerCursor points to the last transaction of the previous page
+type LedgerCursor struct {
+	CreationDate time.Time
+	ID           LedgerTransactionID
+}
+
+// LedgerListSpec selects user transactions, newest first
+type LedgerListSpec struct {
+	UserID UserID
+	Cursor *LedgerCursor
+	Limit  int
+}
+
+func (spec *LedgerListSpec) validate() error {
+	if spec.Limit <= 0 || spec.Limit > MaxLedgerPageSize {
+		return errors.Wrapf(ErrInvalidLedgerListSpec, "limit should be in range 1..%d", MaxLedgerPageSize)
+	}
+	return nil
+}
+
+type LedgerPage struct {
+	Transactions []LedgerTransaction
+	NextCursor   *LedgerCursor
+}
+
+func newLedgerPage(transactions []LedgerTransaction, spec *LedgerListSpec) LedgerPage {
+	if len(transactions) <= spec.Limit {
+		return LedgerPage{Transactions: transactions}
+	}
+	transactions = transactions[:spec.Limit]
+	last := transactions[len(transactions)-1]
+	return LedgerPage{
+		Transactions: transactions,
+		NextCursor: &LedgerCursor{
+			CreationDate: last.CreationDate,
+			ID:           last.ID,
+		},
+	}
+}
+
+type LedgerRepositoryRead interface {
+	// FindByUserID returns up to spec.Limit+1 transactions to detect the next page
+	FindByUserID(spec LedgerListSpec) ([]LedgerTransaction, error)
+	// BalanceBefore returns the user balance made by transactions created before the time
+	BalanceBefore(userID UserID, currency money.Currency, before time.Time) (money.Money, error)
+	// ForEachMovement calls f for user movements of the statement period in chronological order, Balance is not set
+	ForEachMovement(spec StatementSpec, f func(movement StatementMovement) error) error
+}
+
+type LedgerRepository interface {
+	Add(transaction *LedgerTransaction) error
+}
+
+// storeWithLedgerTransaction stores changed account balance together with the transaction explaining the change
+func storeWithLedgerTransaction(provider RepositoryProvider, account *UserAccount, transaction *LedgerTransaction) error {
+	if err := transaction.validate(); err != nil {
+		return err
+	}
+	if err := provider.LedgerRepository().Add(transaction); err != nil {
+		return err
+	}
+	return provider.UserAccountRepository().Store(account)
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrPaymentNotFound = errors.New("payment not found")
+var ErrPaymentMismatch = errors.New("payment for the order already exists with different parameters")
+var ErrInvalidPaymentStatus = errors.New("payment status transition not allowed")
+
+type PaymentID uuid.UUID
+
+type PaymentStatus int
+
+const (
+	// PaymentStatusCaptured payment amount is debited from the account
+	PaymentStatusCaptured PaymentStatus = 0
+	PaymentStatusFailed   PaymentStatus = 1
+	// PaymentStatusAuthorized payment amount is held until capture, void or expiration
+	PaymentStatusAuthorized PaymentStatus = 2
+	PaymentStatusVoided     PaymentStatus = 3
+	PaymentStatusExpired    PaymentStatus = 4
+	PaymentStatusRefunded   PaymentStatus = 5
+)
+
+// Payment is the result of the single payment attempt for the order,
+// repeated attempts for the same order get the stored result instead of charging the account again
+type Payment struct {
+	ID      PaymentID
+	OrderID OrderID
+	UserID  UserID
+	Amount  money.Money
+	// RefundedAmount is the sum of refunds, payment is refunded when the whole amount is returned
+	RefundedAmount money.Money
+	Status         PaymentStatus
+	FailureReason  PaymentFailureReason
+	// ExpirationDate is set for authorized payment, the hold is released after it
+	ExpirationDate *time.Time
+	CreationDate   time.Time
+}
+
+func newPayment(orderID OrderID, userID UserID, amount money.Money) *Payment {
+	return &Payment{
+		ID:             PaymentID(uuid.GenerateNew()),
+		OrderID:        orderID,
+		UserID:         userID,
+		Amount:         amount,
+		RefundedAmount: money.Zero(amount.Currency()),
+		Status:         PaymentStatusCaptured,
+		CreationDate:   time.Now(),
+	}
+}
+
+func (p *Payment) authorize(lifetime time.Duration) {
+	expirationDate := p.CreationDate.Add(lifetime)
+	p.Status = PaymentStatusAuthorized
+	p.ExpirationDate = &expirationDate
+}
+
+func (p *Payment) fail(reason PaymentFailureReason) {
+	p.Status = PaymentStatusFailed
+	p.FailureReason = reason
+}
+
+func (p *Payment) changeStatus(status PaymentStatus, allowedFrom ...PaymentStatus) error {
+	for _, allowed := range allowedFrom {
+		if p.Status == allowed {
+			p.Status = status
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrInvalidPaymentStatus, "payment %s", string(p.ID))
+}
+
+// NotRefundedAmount returns the part of the payment amount which can still be refunded
+func (p *Payment) NotRefundedAmount() (money.Money, error) {
+	return p.Amount.Sub(p.RefundedAmount)
+}
+
+// refund adds amount to refunded amount of captured payment, fully refunded payment becomes refunded
+func (p *Payment) refund(amount money.Money) error {
+	if !amount.IsPositive() {
+		return errors.WithStack(ErrNegativeAmount)
+	}
+	if p.Status != PaymentStatusCaptured {
+		return errors.Wrapf(ErrInvalidPaymentStatus, "payment %s", string(p.ID))
+	}
+	refundedAmount, err := p.RefundedAmount.Add(amount)
+	if err != nil {
+		return err
+	}
+	cmp, err := refundedAmount.Cmp(p.Amount)
+	if err != nil {
+		return err
+	}
+	if cmp > 0 {
+		return errors.Wrapf(ErrRefundExceedsPayment, "payment %s", string(p.ID))
+	}
+	p.RefundedAmount = refundedAmount
+	if cmp == 0 {
+		p.Status = PaymentStatusRefunded
+	}
+	return nil
+}
+
+// checkRepeated rejects reuse of the order payment with another user or amount
+func (p *Payment) checkRepeated(userID UserID, amount money.Money) error {
+	if p.UserID != userID || p.Amount != amount {
+		return errors.Wrapf(ErrPaymentMismatch, "order %s", string(p.OrderID))
+	}
+	return nil
+}
+
+// Err returns the error the failed payment was rejected with
+func (p *Payment) Err() error {
+	if p.Status != PaymentStatusFailed {
+		return nil
+	}
+	switch p.FailureReason {
+	case PaymentFailureAccountNotFound:
+		return errors.WithStack(ErrUserAccountNotFound)
+	case PaymentFailureNotEnoughFunds:
+		return errors.WithStack(ErrNotEnoughFunds)
+	case PaymentFailureCurrencyMismatch:
+		return errors.WithStack(money.ErrCurrencyMismatch)
+	default:
+		return errors.Errorf("payment failed: %s", p.FailureReason)
+	}
+}
+
+type PaymentRepositoryRead interface {
+	FindByOrderID(orderID OrderID) (*Payment, error)
+}
+
+type PaymentRepository interface {
+	PaymentRepositoryRead
+	// FindByOrderIDForUpdate locks the payment as capture, void and expiration may run concurrently
+	FindByOrderIDForUpdate(orderID OrderID) (*Payment, error)
+	// FindByIDForUpdate locks the payment as refunds of it may run concurrently
+	FindByIDForUpdate(id PaymentID) (*Payment, error)
+	// FindExpiredForUpdate returns authorized payments expired before the time skipping ones locked by others
+	FindExpiredForUpdate(before time.Time, limit int) ([]Payment, error)
+	// Store fails for the second payment of the same order, so concurrent attempts can not both charge the account
+	Store(payment *Payment) error
+}
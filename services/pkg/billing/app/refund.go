@@ -0,0 +1,55 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrRefundNotFound = errors.New("refund not found")
+var ErrRefundMismatch = errors.New("refund request already processed with different parameters")
+var ErrRefundExceedsPayment = errors.New("refund amount exceeds not refunded payment amount")
+
+type RefundID uuid.UUID
+
+// Refund returns the part of captured payment to the user account
+type Refund struct {
+	ID RefundID
+	// RequestID is empty for refunds of rejected and cancelled orders
+	RequestID    *RequestID
+	PaymentID    PaymentID
+	OrderID      OrderID
+	UserID       UserID
+	Amount       money.Money
+	CreationDate time.Time
+}
+
+func newRefund(payment *Payment, amount money.Money, requestID *RequestID) *Refund {
+	return &Refund{
+		ID:           RefundID(uuid.GenerateNew()),
+		RequestID:    requestID,
+		PaymentID:    payment.ID,
+		OrderID:      payment.OrderID,
+		UserID:       payment.UserID,
+		Amount:       amount,
+		CreationDate: time.Now(),
+	}
+}
+
+// checkRepeated rejects reuse of the request id for another payment or amount,
+// amount is nil when the request has asked to refund the whole remaining amount
+func (r *Refund) checkRepeated(paymentID PaymentID, amount *money.Money) error {
+	if r.PaymentID != paymentID || (amount != nil && r.Amount != *amount) {
+		return errors.Wrapf(ErrRefundMismatch, "request %s", string(*r.RequestID))
+	}
+	return nil
+}
+
+type RefundRepository interface {
+	FindByRequestID(requestID RequestID) (*Refund, error)
+	// Add fails for the second refund with the same request id
+	Add(refund *Refund) error
+}
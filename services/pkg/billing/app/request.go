@@ -5,7 +5,3 @@ import (
 )
 
 type RequestID uuid.UUID
-
-type ProcessedRequestRepository interface {
-	SetRequestProcessed(uid RequestID) (alreadyProcessed bool, err error)
-}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidStatementPeriod = errors.New("invalid statement period")
+
+// StatementSpec selects user account movements created in [From, To)
+type StatementSpec struct {
+	UserID UserID
+	From   time.Time
+	To     time.Time
+}
+
+func (spec *StatementSpec) validate() error {
+	if !spec.From.Before(spec.To) {
+		return errors.Wrap(ErrInvalidStatementPeriod, "period start should be before its end")
+	}
+	return nil
+}
+
+// StatementMovement is the change of the user balance made by the ledger transaction,
+// Balance is the account balance right after the movement
+type StatementMovement struct {
+	TransactionID LedgerTransactionID
+	Type          LedgerTransactionType
+	RequestID     *RequestID
+	OrderID       *OrderID
+	Amount        money.Money
+	Balance       money.Money
+	CreationDate  time.Time
+}
+
+// StatementWriter receives statement parts in order, so the statement is written out while movements are read
+type StatementWriter interface {
+	WriteOpeningBalance(spec StatementSpec, balance money.Money) error
+	WriteMovement(movement StatementMovement) error
+	WriteClosingBalance(balance money.Money) error
+}
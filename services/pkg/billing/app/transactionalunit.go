@@ -1,9 +1,14 @@
 package app
 
+import "arch-homework/pkg/common/app/storedevent"
+
 type RepositoryProvider interface {
 	UserAccountRepository() UserAccountRepository
+	LedgerRepository() LedgerRepository
+	PaymentRepository() PaymentRepository
+	RefundRepository() RefundRepository
 	ProcessedEventRepository() ProcessedEventRepository
-	ProcessedRequestRepository() ProcessedRequestRepository
+	EventStore() storedevent.EventStore
 }
 
 type TransactionalUnit interface {
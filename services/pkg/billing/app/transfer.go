@@ -0,0 +1,47 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+
+	"github.com/pkg/errors"
+)
+
+var ErrTransferToSelf = errors.New("transfer recipient should differ from sender")
+var ErrRecipientAccountNotFound = errors.New("recipient account not found")
+
+// Transfer moves amount from the sender account to the recipient one, RequestID identifies the transfer
+type Transfer struct {
+	RequestID   RequestID
+	SenderID    UserID
+	RecipientID UserID
+	Amount      money.Money
+}
+
+func (t *Transfer) validate() error {
+	if t.SenderID == t.RecipientID {
+		return errors.WithStack(ErrTransferToSelf)
+	}
+	if !t.Amount.IsPositive() {
+		return errors.WithStack(ErrNegativeAmount)
+	}
+	return nil
+}
+
+// newTransferLedgerTransactions records each side of the transfer in the user's own ledger transaction
+func newTransferLedgerTransactions(transfer *Transfer) (*LedgerTransaction, *LedgerTransaction, error) {
+	senderAmount, err := money.Zero(transfer.Amount.Currency()).Sub(transfer.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	outgoing, err := newLedgerTransaction(LedgerTransactionTransfer, transfer.SenderID, senderAmount, LedgerAccountTransfer)
+	if err != nil {
+		return nil, nil, err
+	}
+	incoming, err := newLedgerTransaction(LedgerTransactionTransfer, transfer.RecipientID, transfer.Amount, LedgerAccountTransfer)
+	if err != nil {
+		return nil, nil, err
+	}
+	outgoing.RequestID = &transfer.RequestID
+	incoming.RequestID = &transfer.RequestID
+	return outgoing, incoming, nil
+}
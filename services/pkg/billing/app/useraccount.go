@@ -1,19 +1,151 @@
 package app
 
 import (
-	"errors"
-
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/uuid"
+
+	"github.com/pkg/errors"
 )
 
 var ErrUserAccountAlreadyExists = errors.New("user account already exists")
 var ErrUserAccountNotFound = errors.New("user account not found")
+var ErrNegativeAmount = errors.New("amount should be positive value")
+var ErrNegativeCreditLimit = errors.New("credit limit should not be negative")
 
 type UserID uuid.UUID
 
+// UserAccount holds balance in the single account currency,
+// Amount is the signed ledger balance and Held is the part of it reserved by authorized payments,
+// CreditLimit is the amount the balance may go negative by, the account is in overdraft while the balance is negative
 type UserAccount struct {
-	UserID UserID
-	Amount Amount
+	UserID      UserID
+	Amount      money.Money
+	Held        money.Money
+	CreditLimit money.Money
+}
+
+func (account *UserAccount) Currency() money.Currency {
+	return account.Amount.Currency()
+}
+
+// Available returns amount that can be spent or held including the credit limit
+func (account *UserAccount) Available() (money.Money, error) {
+	available, err := account.Amount.Add(account.CreditLimit)
+	if err != nil {
+		return available, err
+	}
+	return available.Sub(account.Held)
+}
+
+// SetCreditLimit changes the limit, lowering it below the current overdraft only prevents further spending
+func (account *UserAccount) SetCreditLimit(limit money.Money) error {
+	if limit.IsNegative() {
+		return errors.WithStack(ErrNegativeCreditLimit)
+	}
+	if limit.Currency() != account.Currency() {
+		return errors.WithStack(money.ErrCurrencyMismatch)
+	}
+	account.CreditLimit = limit
+	return nil
+}
+
+func (account *UserAccount) InOverdraft() bool {
+	return account.Amount.IsNegative()
+}
+
+// enteredOverdraft reports whether debit of the amount has made non-negative balance negative
+func (account *UserAccount) enteredOverdraft(debited money.Money) (bool, error) {
+	if !account.InOverdraft() {
+		return false, nil
+	}
+	previous, err := account.Amount.Add(debited)
+	if err != nil {
+		return false, err
+	}
+	return !previous.IsNegative(), nil
+}
+
+func (account *UserAccount) Credit(amount money.Money) error {
+	if !amount.IsPositive() {
+		return errors.WithStack(ErrNegativeAmount)
+	}
+	balance, err := account.Amount.Add(amount)
+	if err != nil {
+		return err
+	}
+	account.Amount = balance
+	return nil
+}
+
+func (account *UserAccount) Debit(amount money.Money) error {
+	if !amount.IsPositive() {
+		return errors.WithStack(ErrNegativeAmount)
+	}
+	if err := account.checkAvailable(amount); err != nil {
+		return err
+	}
+	balance, err := account.Amount.Sub(amount)
+	if err != nil {
+		return err
+	}
+	account.Amount = balance
+	return nil
+}
+
+// Hold reserves amount for the authorized payment, ledger balance stays the same
+func (account *UserAccount) Hold(amount money.Money) error {
+	if !amount.IsPositive() {
+		return errors.WithStack(ErrNegativeAmount)
+	}
+	if err := account.checkAvailable(amount); err != nil {
+		return err
+	}
+	held, err := account.Held.Add(amount)
+	if err != nil {
+		return err
+	}
+	account.Held = held
+	return nil
+}
+
+// ReleaseHold returns held amount to available balance
+func (account *UserAccount) ReleaseHold(amount money.Money) error {
+	cmp, err := account.Held.Cmp(amount)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return errors.Errorf("held amount %s is less than released %s", account.Held, amount)
+	}
+	held, err := account.Held.Sub(amount)
+	if err != nil {
+		return err
+	}
+	account.Held = held
+	return nil
+}
+
+// CaptureHold debits previously held amount
+func (account *UserAccount) CaptureHold(amount money.Money) error {
+	if err := account.ReleaseHold(amount); err != nil {
+		return err
+	}
+	return account.Debit(amount)
+}
+
+func (account *UserAccount) checkAvailable(amount money.Money) error {
+	available, err := account.Available()
+	if err != nil {
+		return err
+	}
+	cmp, err := available.Cmp(amount)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		return errors.WithStack(ErrNotEnoughFunds)
+	}
+	return nil
 }
 
 type UserAccountRepositoryRead interface {
@@ -22,5 +154,8 @@ type UserAccountRepositoryRead interface {
 
 type UserAccountRepository interface {
 	UserAccountRepositoryRead
+	// FindByIDForUpdate locks the account until the transaction ends, so concurrent balance changes are serialized
+	// and each of them checks available funds against the latest balance
+	FindByIDForUpdate(id UserID) (*UserAccount, error)
 	Store(userAccount *UserAccount) error
 }
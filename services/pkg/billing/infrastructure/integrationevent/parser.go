@@ -3,6 +3,7 @@ package integrationevent
 import (
 	"arch-homework/pkg/billing/app"
 	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/uuid"
 
 	"encoding/json"
@@ -11,6 +12,10 @@ import (
 )
 
 const typeUserRegistered = "auth.user_registered"
+const typeOrderCreated = "order.order_created"
+const typeOrderConfirmed = "order.order_confirmed"
+const typeOrderRejected = "order.order_rejected"
+const typeOrderCancelled = "order.order_cancelled"
 
 func NewEventParser() app.IntegrationEventParser {
 	return eventParser{}
@@ -23,6 +28,14 @@ func (e eventParser) ParseIntegrationEvent(event integrationevent.EventData) (ap
 	switch event.Type {
 	case typeUserRegistered:
 		return parseUserRegisteredEvent(event.Body)
+	case typeOrderCreated:
+		return parseOrderCreatedEvent(event.Body)
+	case typeOrderConfirmed:
+		return parseOrderConfirmedEvent(event.Body)
+	case typeOrderRejected:
+		return parseOrderRejectedEvent(event.Body)
+	case typeOrderCancelled:
+		return parseOrderCancelledEvent(event.Body)
 	default:
 		return nil, nil
 	}
@@ -41,7 +54,65 @@ func parseUserRegisteredEvent(strBody string) (app.UserEvent, error) {
 	return app.NewUserRegisteredEvent(app.UserID(body.UserID), body.Login), nil
 }
 
+func parseOrderCreatedEvent(strBody string) (app.UserEvent, error) {
+	body, price, err := parseOrderEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewOrderCreatedEvent(app.UserID(body.UserID), app.OrderID(body.OrderID), price), nil
+}
+
+func parseOrderConfirmedEvent(strBody string) (app.UserEvent, error) {
+	body, _, err := parseOrderEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewOrderConfirmedEvent(app.UserID(body.UserID), app.OrderID(body.OrderID)), nil
+}
+
+// parseOrderRejectedEvent skips rejected orders without authorized payment
+func parseOrderRejectedEvent(strBody string) (app.UserEvent, error) {
+	body, price, err := parseOrderEvent(strBody)
+	if err != nil || !body.RefundPayment {
+		return nil, err
+	}
+	return app.NewOrderRejectedEvent(app.UserID(body.UserID), app.OrderID(body.OrderID), price), nil
+}
+
+func parseOrderCancelledEvent(strBody string) (app.UserEvent, error) {
+	body, price, err := parseOrderEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewOrderCancelledEvent(app.UserID(body.UserID), app.OrderID(body.OrderID), price), nil
+}
+
+func parseOrderEvent(strBody string) (orderEventBody, money.Money, error) {
+	var body orderEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return body, money.Money{}, errors.WithStack(err)
+	}
+	err = uuid.ValidateUUID(body.UserID)
+	if err != nil {
+		return body, money.Money{}, errors.WithStack(err)
+	}
+	err = uuid.ValidateUUID(body.OrderID)
+	if err != nil {
+		return body, money.Money{}, errors.WithStack(err)
+	}
+	return body, body.Price, nil
+}
+
 type userRegisteredEventBody struct {
 	UserID string `json:"user_id"`
 	Login  string `json:"login"`
 }
+
+type orderEventBody struct {
+	OrderID string      `json:"order_id"`
+	UserID  string      `json:"user_id"`
+	Price   money.Money `json:"price"`
+	// RefundPayment is set only in order rejected event, held or paid amount should be returned
+	RefundPayment bool `json:"refund_payment"`
+}
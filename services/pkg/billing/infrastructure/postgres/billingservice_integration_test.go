@@ -0,0 +1,207 @@
+//go:build integration
+// +build integration
+
+package postgres_test
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/billing/infrastructure/postgres"
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+	commonpostgres "arch-homework/pkg/common/infrastructure/postgres"
+
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Tests run against the database migrated by the billing migration job:
+//   DB_HOST=localhost DB_PORT=5433 DB_NAME=hw-db DB_USER=hw-user DB_PASSWORD=hw-pwd go test -tags integration ./pkg/billing/...
+
+const concurrentCalls = 20
+
+func TestConcurrentPaymentsStayWithinCreditLimit(t *testing.T) {
+	env := newTestEnvironment(t)
+	// 100.00 balance and 50.00 credit limit cover exactly 15 payments of 10.00
+	userID := env.createAccount(t, money.New(10000, env.currency), money.New(5000, env.currency))
+	creditLimit := money.New(5000, env.currency)
+
+	stopMonitor := env.monitorBalance(t, userID, creditLimit)
+	var notEnoughFunds int64
+	runConcurrently(concurrentCalls, func(i int) {
+		_, err := env.service.ProcessPayment(app.OrderID(uuid.GenerateNew()), userID, money.New(1000, env.currency))
+		if errors.Cause(err) == app.ErrNotEnoughFunds {
+			atomic.AddInt64(&notEnoughFunds, 1)
+		} else if err != nil {
+			t.Errorf("payment failed: %v", err)
+		}
+	})
+	stopMonitor()
+
+	if notEnoughFunds != concurrentCalls-15 {
+		t.Errorf("expected %d payments failed with not enough funds, got %d", concurrentCalls-15, notEnoughFunds)
+	}
+	env.checkBalance(t, userID, money.New(-5000, env.currency))
+}
+
+func TestConcurrentPaymentsAndTopUps(t *testing.T) {
+	env := newTestEnvironment(t)
+	// 200.00 balance covers all payments whatever order top-ups are applied in
+	userID := env.createAccount(t, money.New(20000, env.currency), money.Zero(env.currency))
+
+	stopMonitor := env.monitorBalance(t, userID, money.Zero(env.currency))
+	var notEnoughFunds int64
+	runConcurrently(concurrentCalls*2, func(i int) {
+		if i%2 == 0 {
+			if err := env.service.TopUpAccount(app.RequestID(uuid.GenerateNew()), userID, money.New(500, env.currency)); err != nil {
+				t.Errorf("top-up failed: %v", err)
+			}
+			return
+		}
+		_, err := env.service.ProcessPayment(app.OrderID(uuid.GenerateNew()), userID, money.New(1000, env.currency))
+		if errors.Cause(err) == app.ErrNotEnoughFunds {
+			atomic.AddInt64(&notEnoughFunds, 1)
+		} else if err != nil {
+			t.Errorf("payment failed: %v", err)
+		}
+	})
+	stopMonitor()
+
+	if notEnoughFunds != 0 {
+		t.Errorf("expected no payments failed with not enough funds, got %d", notEnoughFunds)
+	}
+	// 200.00 + 20 top-ups of 5.00 - 20 payments of 10.00
+	env.checkBalance(t, userID, money.New(10000, env.currency))
+}
+
+type testEnvironment struct {
+	service     app.BillingService
+	accountRepo app.UserAccountRepositoryRead
+	currency    money.Currency
+}
+
+func newTestEnvironment(t *testing.T) *testEnvironment {
+	dsn := commonpostgres.DSN{
+		User:     os.Getenv("DB_USER"),
+		Password: os.Getenv("DB_PASSWORD"),
+		Host:     os.Getenv("DB_HOST"),
+		Port:     os.Getenv("DB_PORT"),
+		Database: os.Getenv("DB_NAME"),
+	}
+	if dsn.User == "" || dsn.Password == "" || dsn.Host == "" || dsn.Port == "" || dsn.Database == "" {
+		t.Skip("db env params not set")
+	}
+
+	connector := commonpostgres.NewConnector()
+	if err := connector.Open(dsn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = connector.Close()
+	})
+	if err := connector.WaitUntilReady(); err != nil {
+		t.Fatal(err)
+	}
+
+	currency, err := money.ParseCurrency("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := connector.Client()
+	return &testEnvironment{
+		service:     app.NewBillingService(postgres.NewTransactionalUnitFactory(client), eventSender{}, currency, time.Hour),
+		accountRepo: postgres.NewUserAccountRepository(client),
+		currency:    currency,
+	}
+}
+
+func (env *testEnvironment) createAccount(t *testing.T, balance, creditLimit money.Money) app.UserID {
+	userID := app.UserID(uuid.GenerateNew())
+	if err := env.service.CreateAccount(userID); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.service.TopUpAccount(app.RequestID(uuid.GenerateNew()), userID, balance); err != nil {
+		t.Fatal(err)
+	}
+	if !creditLimit.IsZero() {
+		if _, err := env.service.SetCreditLimit(userID, creditLimit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return userID
+}
+
+// monitorBalance polls the committed balance while calls are running and reports it going below -creditLimit
+func (env *testEnvironment) monitorBalance(t *testing.T, userID app.UserID, creditLimit money.Money) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			account, err := env.accountRepo.FindByID(userID)
+			if err != nil {
+				t.Errorf("failed to read account: %v", err)
+				return
+			}
+			available, err := account.Amount.Add(creditLimit)
+			if err != nil {
+				t.Errorf("failed to check balance: %v", err)
+				return
+			}
+			if available.IsNegative() {
+				t.Errorf("balance %s is below credit limit %s", account.Amount, creditLimit)
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (env *testEnvironment) checkBalance(t *testing.T, userID app.UserID, expected money.Money) {
+	account, err := env.accountRepo.FindByID(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Amount != expected {
+		t.Errorf("expected balance %s, got %s", expected, account.Amount)
+	}
+	if !account.Held.IsZero() {
+		t.Errorf("expected nothing held, got %s", account.Held)
+	}
+}
+
+// runConcurrently starts all calls at once to make them contend for the account lock
+func runConcurrently(n int, call func(i int)) {
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			call(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+}
+
+// eventSender leaves events in the event store, the tests check balances only
+type eventSender struct{}
+
+func (eventSender) EventStored(integrationevent.EventUID) {}
+
+func (eventSender) SendStoredEvents() {}
@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/infrastructure/postgres"
+
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+func NewLedgerRepository(client postgres.Client) app.LedgerRepository {
+	return &ledgerRepository{client: client}
+}
+
+func NewLedgerRepositoryRead(client postgres.Client) app.LedgerRepositoryRead {
+	return &ledgerRepository{client: client}
+}
+
+type ledgerRepository struct {
+	client postgres.Client
+}
+
+func (repo *ledgerRepository) Add(transaction *app.LedgerTransaction) error {
+	const transactionQuery = `
+			INSERT INTO ledger_transaction (id, type, user_id, request_id, order_id, created_at)
+			VALUES (:id, :type, :user_id, :request_id, :order_id, :created_at)
+		`
+	const entryQuery = `
+			INSERT INTO ledger_entry (transaction_id, account, amount, currency)
+			VALUES (:transaction_id, :account, :amount, :currency)
+		`
+
+	transactionx := sqlxLedgerTransaction{
+		ID:           string(transaction.ID),
+		Type:         string(transaction.Type),
+		UserID:       string(transaction.UserID),
+		CreationDate: transaction.CreationDate,
+	}
+	if transaction.RequestID != nil {
+		transactionx.RequestID = sql.NullString{String: string(*transaction.RequestID), Valid: true}
+	}
+	if transaction.OrderID != nil {
+		transactionx.OrderID = sql.NullString{String: string(*transaction.OrderID), Valid: true}
+	}
+	_, err := repo.client.NamedExec(transactionQuery, &transactionx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, entry := range transaction.Entries {
+		entryx := sqlxLedgerEntry{
+			TransactionID: string(transaction.ID),
+			Account:       string(entry.Account),
+			Amount:        entry.Amount.MinorUnits(),
+			Currency:      string(entry.Amount.Currency()),
+		}
+		_, err = repo.client.NamedExec(entryQuery, &entryx)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (repo *ledgerRepository) FindByUserID(spec app.LedgerListSpec) ([]app.LedgerTransaction, error) {
+	query := `SELECT id, type, user_id, request_id, order_id, created_at FROM ledger_transaction WHERE user_id = ?`
+	params := []interface{}{string(spec.UserID)}
+	if spec.Cursor != nil {
+		query += ` AND (created_at, id) < (?, ?)`
+		params = append(params, spec.Cursor.CreationDate, string(spec.Cursor.ID))
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	params = append(params, spec.Limit+1)
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	var transactions []*sqlxLedgerTransaction
+	err := repo.client.Select(&transactions, query, params...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return repo.withEntries(transactions)
+}
+
+func (repo *ledgerRepository) BalanceBefore(userID app.UserID, currency money.Currency, before time.Time) (money.Money, error) {
+	const query = `
+			SELECT COALESCE(SUM(e.amount), 0) FROM ledger_entry e
+			JOIN ledger_transaction t ON t.id = e.transaction_id
+			WHERE t.user_id = $1 AND e.account = $2 AND t.created_at < $3
+		`
+
+	var amount int64
+	err := repo.client.Get(&amount, query, string(userID), string(app.UserLedgerAccount(userID)), before)
+	if err != nil {
+		return money.Money{}, errors.WithStack(err)
+	}
+	return money.New(amount, currency), nil
+}
+
+func (repo *ledgerRepository) ForEachMovement(spec app.StatementSpec, f func(movement app.StatementMovement) error) (err error) {
+	const query = `
+			SELECT t.id, t.type, t.request_id, t.order_id, t.created_at, e.amount, e.currency FROM ledger_transaction t
+			JOIN ledger_entry e ON e.transaction_id = t.id AND e.account = $1
+			WHERE t.user_id = $2 AND t.created_at >= $3 AND t.created_at < $4
+			ORDER BY t.created_at, t.id
+		`
+
+	rows, err := repo.client.Queryx(query, string(app.UserLedgerAccount(spec.UserID)), string(spec.UserID), spec.From, spec.To)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); err == nil {
+			err = errors.WithStack(closeErr)
+		}
+	}()
+
+	for rows.Next() {
+		var movement sqlxStatementMovement
+		if err = rows.StructScan(&movement); err != nil {
+			return errors.WithStack(err)
+		}
+		if err = f(sqlxMovementToMovement(&movement)); err != nil {
+			return err
+		}
+	}
+	return errors.WithStack(rows.Err())
+}
+
+func (repo *ledgerRepository) withEntries(transactions []*sqlxLedgerTransaction) ([]app.LedgerTransaction, error) {
+	res := make([]app.LedgerTransaction, 0, len(transactions))
+	if len(transactions) == 0 {
+		return res, nil
+	}
+
+	const sqlQuery = `SELECT transaction_id, account, amount, currency FROM ledger_entry WHERE transaction_id IN (?) ORDER BY id`
+
+	transactionIDs := make([]string, 0, len(transactions))
+	for _, transaction := range transactions {
+		transactionIDs = append(transactionIDs, transaction.ID)
+	}
+	query, params, err := sqlx.In(sqlQuery, transactionIDs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	var entries []*sqlxLedgerEntry
+	err = repo.client.Select(&entries, query, params...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	entriesByTransaction := make(map[string][]app.LedgerEntry, len(transactions))
+	for _, entry := range entries {
+		entriesByTransaction[entry.TransactionID] = append(entriesByTransaction[entry.TransactionID], app.LedgerEntry{
+			Account: app.LedgerAccount(entry.Account),
+			Amount:  money.New(entry.Amount, money.Currency(entry.Currency)),
+		})
+	}
+	for _, transaction := range transactions {
+		res = append(res, sqlxLedgerTransactionToTransaction(transaction, entriesByTransaction[transaction.ID]))
+	}
+	return res, nil
+}
+
+func sqlxLedgerTransactionToTransaction(transaction *sqlxLedgerTransaction, entries []app.LedgerEntry) app.LedgerTransaction {
+	res := app.LedgerTransaction{
+		ID:           app.LedgerTransactionID(transaction.ID),
+		Type:         app.LedgerTransactionType(transaction.Type),
+		UserID:       app.UserID(transaction.UserID),
+		Entries:      entries,
+		CreationDate: transaction.CreationDate,
+	}
+	if transaction.RequestID.Valid {
+		requestID := app.RequestID(transaction.RequestID.String)
+		res.RequestID = &requestID
+	}
+	if transaction.OrderID.Valid {
+		orderID := app.OrderID(transaction.OrderID.String)
+		res.OrderID = &orderID
+	}
+	return res
+}
+
+func sqlxMovementToMovement(movement *sqlxStatementMovement) app.StatementMovement {
+	res := app.StatementMovement{
+		TransactionID: app.LedgerTransactionID(movement.TransactionID),
+		Type:          app.LedgerTransactionType(movement.Type),
+		Amount:        money.New(movement.Amount, money.Currency(movement.Currency)),
+		CreationDate:  movement.CreationDate,
+	}
+	if movement.RequestID.Valid {
+		requestID := app.RequestID(movement.RequestID.String)
+		res.RequestID = &requestID
+	}
+	if movement.OrderID.Valid {
+		orderID := app.OrderID(movement.OrderID.String)
+		res.OrderID = &orderID
+	}
+	return res
+}
+
+type sqlxLedgerTransaction struct {
+	ID           string         `db:"id"`
+	Type         string         `db:"type"`
+	UserID       string         `db:"user_id"`
+	RequestID    sql.NullString `db:"request_id"`
+	OrderID      sql.NullString `db:"order_id"`
+	CreationDate time.Time      `db:"created_at"`
+}
+
+type sqlxLedgerEntry struct {
+	TransactionID string `db:"transaction_id"`
+	Account       string `db:"account"`
+	Amount        int64  `db:"amount"`
+	Currency      string `db:"currency"`
+}
+
+type sqlxStatementMovement struct {
+	TransactionID string         `db:"id"`
+	Type          string         `db:"type"`
+	RequestID     sql.NullString `db:"request_id"`
+	OrderID       sql.NullString `db:"order_id"`
+	CreationDate  time.Time      `db:"created_at"`
+	Amount        int64          `db:"amount"`
+	Currency      string         `db:"currency"`
+}
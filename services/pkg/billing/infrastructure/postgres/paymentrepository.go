@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/infrastructure/postgres"
+
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const selectPaymentQuery = `SELECT id, order_id, user_id, amount, refunded_amount, currency, status, failure_reason, expires_at, created_at FROM payment`
+
+func NewPaymentRepository(client postgres.Client) app.PaymentRepository {
+	return &paymentRepository{client: client}
+}
+
+type paymentRepository struct {
+	client postgres.Client
+}
+
+func (repo *paymentRepository) Store(payment *app.Payment) error {
+	const query = `
+			INSERT INTO payment (id, order_id, user_id, amount, currency, status, failure_reason, expires_at, created_at)
+			VALUES (:id, :order_id, :user_id, :amount, :currency, :status, :failure_reason, :expires_at, :created_at)
+			ON CONFLICT (id) DO UPDATE SET
+				status = excluded.status,
+				expires_at = excluded.expires_at
+		`
+
+	paymentx := sqlxPayment{
+		ID:             string(payment.ID),
+		OrderID:        string(payment.OrderID),
+		UserID:         string(payment.UserID),
+		Amount:         payment.Amount.MinorUnits(),
+		RefundedAmount: payment.RefundedAmount.MinorUnits(),
+		Currency:       string(payment.Amount.Currency()),
+		Status:         int(payment.Status),
+		FailureReason:  string(payment.FailureReason),
+		CreationDate:   payment.CreationDate,
+	}
+	if payment.ExpirationDate != nil {
+		paymentx.ExpirationDate = sql.NullTime{Time: *payment.ExpirationDate, Valid: true}
+	}
+
+	_, err := repo.client.NamedExec(query, &paymentx)
+	return errors.WithStack(err)
+}
+
+func (repo *paymentRepository) FindByOrderID(orderID app.OrderID) (*app.Payment, error) {
+	return repo.find(selectPaymentQuery+` WHERE order_id = $1`, string(orderID))
+}
+
+func (repo *paymentRepository) FindByOrderIDForUpdate(orderID app.OrderID) (*app.Payment, error) {
+	return repo.find(selectPaymentQuery+` WHERE order_id = $1 FOR UPDATE`, string(orderID))
+}
+
+func (repo *paymentRepository) FindByIDForUpdate(id app.PaymentID) (*app.Payment, error) {
+	return repo.find(selectPaymentQuery+` WHERE id = $1 FOR UPDATE`, string(id))
+}
+
+func (repo *paymentRepository) FindExpiredForUpdate(before time.Time, limit int) ([]app.Payment, error) {
+	const query = selectPaymentQuery + ` WHERE status = $1 AND expires_at < $2 ORDER BY expires_at LIMIT $3 FOR UPDATE SKIP LOCKED`
+
+	var payments []*sqlxPayment
+	err := repo.client.Select(&payments, query, int(app.PaymentStatusAuthorized), before, limit)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	res := make([]app.Payment, 0, len(payments))
+	for _, payment := range payments {
+		res = append(res, sqlxPaymentToPayment(payment))
+	}
+	return res, nil
+}
+
+func (repo *paymentRepository) find(query string, param string) (*app.Payment, error) {
+	var payment sqlxPayment
+	err := repo.client.Get(&payment, query, param)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.WithStack(app.ErrPaymentNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	res := sqlxPaymentToPayment(&payment)
+	return &res, nil
+}
+
+func sqlxPaymentToPayment(payment *sqlxPayment) app.Payment {
+	res := app.Payment{
+		ID:             app.PaymentID(payment.ID),
+		OrderID:        app.OrderID(payment.OrderID),
+		UserID:         app.UserID(payment.UserID),
+		Amount:         money.New(payment.Amount, money.Currency(payment.Currency)),
+		RefundedAmount: money.New(payment.RefundedAmount, money.Currency(payment.Currency)),
+		Status:         app.PaymentStatus(payment.Status),
+		FailureReason:  app.PaymentFailureReason(payment.FailureReason),
+		CreationDate:   payment.CreationDate,
+	}
+	if payment.ExpirationDate.Valid {
+		expirationDate := payment.ExpirationDate.Time
+		res.ExpirationDate = &expirationDate
+	}
+	return res
+}
+
+type sqlxPayment struct {
+	ID             string       `db:"id"`
+	OrderID        string       `db:"order_id"`
+	UserID         string       `db:"user_id"`
+	Amount         int64        `db:"amount"`
+	RefundedAmount int64        `db:"refunded_amount"`
+	Currency       string       `db:"currency"`
+	Status         int          `db:"status"`
+	FailureReason  string       `db:"failure_reason"`
+	ExpirationDate sql.NullTime `db:"expires_at"`
+	CreationDate   time.Time    `db:"created_at"`
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/infrastructure/postgres"
+
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewRefundRepository(client postgres.Client) app.RefundRepository {
+	return &refundRepository{client: client}
+}
+
+type refundRepository struct {
+	client postgres.Client
+}
+
+func (repo *refundRepository) FindByRequestID(requestID app.RequestID) (*app.Refund, error) {
+	const query = `SELECT id, request_id, payment_id, order_id, user_id, amount, currency, created_at FROM refund WHERE request_id = $1`
+
+	var refund sqlxRefund
+	err := repo.client.Get(&refund, query, string(requestID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.WithStack(app.ErrRefundNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	res := sqlxRefundToRefund(&refund)
+	return &res, nil
+}
+
+func (repo *refundRepository) Add(refund *app.Refund) error {
+	const query = `
+			INSERT INTO refund (id, request_id, payment_id, order_id, user_id, amount, currency, created_at)
+			VALUES (:id, :request_id, :payment_id, :order_id, :user_id, :amount, :currency, :created_at)
+		`
+
+	refundx := sqlxRefund{
+		ID:           string(refund.ID),
+		PaymentID:    string(refund.PaymentID),
+		OrderID:      string(refund.OrderID),
+		UserID:       string(refund.UserID),
+		Amount:       refund.Amount.MinorUnits(),
+		Currency:     string(refund.Amount.Currency()),
+		CreationDate: refund.CreationDate,
+	}
+	if refund.RequestID != nil {
+		refundx.RequestID = sql.NullString{String: string(*refund.RequestID), Valid: true}
+	}
+
+	_, err := repo.client.NamedExec(query, &refundx)
+	return errors.WithStack(err)
+}
+
+func sqlxRefundToRefund(refund *sqlxRefund) app.Refund {
+	res := app.Refund{
+		ID:           app.RefundID(refund.ID),
+		PaymentID:    app.PaymentID(refund.PaymentID),
+		OrderID:      app.OrderID(refund.OrderID),
+		UserID:       app.UserID(refund.UserID),
+		Amount:       money.New(refund.Amount, money.Currency(refund.Currency)),
+		CreationDate: refund.CreationDate,
+	}
+	if refund.RequestID.Valid {
+		requestID := app.RequestID(refund.RequestID.String)
+		res.RequestID = &requestID
+	}
+	return res
+}
+
+type sqlxRefund struct {
+	ID           string         `db:"id"`
+	RequestID    sql.NullString `db:"request_id"`
+	PaymentID    string         `db:"payment_id"`
+	OrderID      string         `db:"order_id"`
+	UserID       string         `db:"user_id"`
+	Amount       int64          `db:"amount"`
+	Currency     string         `db:"currency"`
+	CreationDate time.Time      `db:"created_at"`
+}
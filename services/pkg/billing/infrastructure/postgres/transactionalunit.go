@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/storedevent"
 	"arch-homework/pkg/common/infrastructure/postgres"
 
 	"github.com/pkg/errors"
@@ -38,12 +39,24 @@ func (t *transactionalUnit) UserAccountRepository() app.UserAccountRepository {
 	return NewUserAccountRepository(t.transaction)
 }
 
+func (t *transactionalUnit) LedgerRepository() app.LedgerRepository {
+	return NewLedgerRepository(t.transaction)
+}
+
+func (t *transactionalUnit) PaymentRepository() app.PaymentRepository {
+	return NewPaymentRepository(t.transaction)
+}
+
+func (t *transactionalUnit) RefundRepository() app.RefundRepository {
+	return NewRefundRepository(t.transaction)
+}
+
 func (t *transactionalUnit) ProcessedEventRepository() app.ProcessedEventRepository {
 	return NewProcessedEventRepository(t.transaction)
 }
 
-func (t *transactionalUnit) ProcessedRequestRepository() app.ProcessedRequestRepository {
-	return NewProcessedRequestRepository(t.transaction)
+func (t *transactionalUnit) EventStore() storedevent.EventStore {
+	return NewEventStore(t.transaction)
 }
 
 func (t *transactionalUnit) Complete(err error) error {
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/infrastructure/postgres"
 
 	"database/sql"
@@ -9,6 +10,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+const selectUserAccountQuery = `SELECT user_id, amount, held, credit_limit, currency FROM user_account`
+
 func NewUserAccountRepository(client postgres.Client) app.UserAccountRepository {
 	return &userAccountRepository{client: client}
 }
@@ -19,14 +22,17 @@ type userAccountRepository struct {
 
 func (repo *userAccountRepository) Store(userAccount *app.UserAccount) error {
 	const query = `
-			INSERT INTO user_account (user_id, amount)
-			VALUES (:user_id, :amount)
-			ON CONFLICT (user_id) DO UPDATE SET amount = excluded.amount
+			INSERT INTO user_account (user_id, amount, held, credit_limit, currency)
+			VALUES (:user_id, :amount, :held, :credit_limit, :currency)
+			ON CONFLICT (user_id) DO UPDATE SET amount = excluded.amount, held = excluded.held, credit_limit = excluded.credit_limit
 		`
 
 	userAccountx := sqlxUserAccount{
-		UserID: string(userAccount.UserID),
-		Amount: userAccount.Amount.RawValue(),
+		UserID:      string(userAccount.UserID),
+		Amount:      userAccount.Amount.MinorUnits(),
+		Held:        userAccount.Held.MinorUnits(),
+		CreditLimit: userAccount.CreditLimit.MinorUnits(),
+		Currency:    string(userAccount.Currency()),
 	}
 
 	_, err := repo.client.NamedExec(query, &userAccountx)
@@ -34,8 +40,14 @@ func (repo *userAccountRepository) Store(userAccount *app.UserAccount) error {
 }
 
 func (repo *userAccountRepository) FindByID(id app.UserID) (*app.UserAccount, error) {
-	const query = `SELECT user_id, amount FROM user_account WHERE user_id = $1`
+	return repo.find(selectUserAccountQuery+` WHERE user_id = $1`, id)
+}
+
+func (repo *userAccountRepository) FindByIDForUpdate(id app.UserID) (*app.UserAccount, error) {
+	return repo.find(selectUserAccountQuery+` WHERE user_id = $1 FOR UPDATE`, id)
+}
 
+func (repo *userAccountRepository) find(query string, id app.UserID) (*app.UserAccount, error) {
 	var user sqlxUserAccount
 	err := repo.client.Get(&user, query, string(id))
 	if err != nil {
@@ -45,13 +57,18 @@ func (repo *userAccountRepository) FindByID(id app.UserID) (*app.UserAccount, er
 		return nil, errors.WithStack(err)
 	}
 	res := app.UserAccount{
-		UserID: app.UserID(user.UserID),
-		Amount: app.AmountFromRawValue(user.Amount),
+		UserID:      app.UserID(user.UserID),
+		Amount:      money.New(user.Amount, money.Currency(user.Currency)),
+		Held:        money.New(user.Held, money.Currency(user.Currency)),
+		CreditLimit: money.New(user.CreditLimit, money.Currency(user.Currency)),
 	}
 	return &res, nil
 }
 
 type sqlxUserAccount struct {
-	UserID string `db:"user_id"`
-	Amount uint64 `db:"amount"`
+	UserID      string `db:"user_id"`
+	Amount      int64  `db:"amount"`
+	Held        int64  `db:"held"`
+	CreditLimit int64  `db:"credit_limit"`
+	Currency    string `db:"currency"`
 }
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+var errInvalidUserID = errors.New("invalid user id")
+
+func (s *Server) getAdminAccountEndpoint(w http.ResponseWriter, r *http.Request) error {
+	userID, err := getUserIDFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.billingQueryService.Account(userID)
+	if err != nil {
+		return err
+	}
+	return writeAdminAccountResponse(w, account)
+}
+
+func (s *Server) setCreditLimitEndpoint(w http.ResponseWriter, r *http.Request) error {
+	userID, err := getUserIDFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	var info creditLimitInfo
+	bytesBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	_ = r.Body.Close()
+	if err = json.Unmarshal(bytesBody, &info); err != nil {
+		return err
+	}
+
+	account, err := s.billingService.SetCreditLimit(userID, info.CreditLimit)
+	if err != nil {
+		return err
+	}
+	return writeAdminAccountResponse(w, account)
+}
+
+func getUserIDFromRequest(r *http.Request) (app.UserID, error) {
+	vars := mux.Vars(r)
+	userID := vars["userId"]
+	if err := uuid.ValidateUUID(userID); err != nil {
+		return "", errors.Wrap(errInvalidUserID, err.Error())
+	}
+	return app.UserID(userID), nil
+}
+
+func writeAdminAccountResponse(w http.ResponseWriter, account *app.UserAccount) error {
+	status, err := toAccountStatusResponse(account)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, adminAccountResponse{
+		UserID:                string(account.UserID),
+		InOverdraft:           account.InOverdraft(),
+		accountStatusResponse: status,
+	})
+	return nil
+}
+
+type creditLimitInfo struct {
+	CreditLimit money.Money `json:"creditLimit"`
+}
+
+type adminAccountResponse struct {
+	UserID      string `json:"userId"`
+	InOverdraft bool   `json:"inOverdraft"`
+	accountStatusResponse
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	paymentStatusCaptured   = "captured"
+	paymentStatusFailed     = "failed"
+	paymentStatusAuthorized = "authorized"
+	paymentStatusVoided     = "voided"
+	paymentStatusExpired    = "expired"
+	paymentStatusRefunded   = "refunded"
+)
+
+func (s *Server) processPaymentEndpoint(w http.ResponseWriter, r *http.Request) error {
+	info, err := readPaymentInfo(r)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.billingService.ProcessPayment(app.OrderID(info.OrderID), app.UserID(info.UserID), info.Amount)
+	if err != nil {
+		return err
+	}
+	return writePaymentResponse(w, payment)
+}
+
+func (s *Server) authorizePaymentEndpoint(w http.ResponseWriter, r *http.Request) error {
+	info, err := readPaymentInfo(r)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.billingService.AuthorizePayment(app.OrderID(info.OrderID), app.UserID(info.UserID), info.Amount)
+	if err != nil {
+		return err
+	}
+	return writePaymentResponse(w, payment)
+}
+
+func (s *Server) capturePaymentEndpoint(w http.ResponseWriter, r *http.Request) error {
+	orderID, err := getOrderIDFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.billingService.CapturePayment(orderID)
+	if err != nil {
+		return err
+	}
+	return writePaymentResponse(w, payment)
+}
+
+func (s *Server) voidPaymentEndpoint(w http.ResponseWriter, r *http.Request) error {
+	orderID, err := getOrderIDFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	payment, err := s.billingService.VoidPayment(orderID)
+	if err != nil {
+		return err
+	}
+	return writePaymentResponse(w, payment)
+}
+
+func readPaymentInfo(r *http.Request) (paymentInfo, error) {
+	var info paymentInfo
+	bytesBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return info, err
+	}
+	_ = r.Body.Close()
+	if err = json.Unmarshal(bytesBody, &info); err != nil {
+		return info, err
+	}
+	if err = uuid.ValidateUUID(info.OrderID); err != nil {
+		return info, errors.Wrap(errInvalidPaymentData, err.Error())
+	}
+	if err = uuid.ValidateUUID(info.UserID); err != nil {
+		return info, errors.Wrap(errInvalidPaymentData, err.Error())
+	}
+	return info, nil
+}
+
+func getOrderIDFromRequest(r *http.Request) (app.OrderID, error) {
+	vars := mux.Vars(r)
+	orderID := vars["orderId"]
+	if err := uuid.ValidateUUID(orderID); err != nil {
+		return "", errors.Wrap(errInvalidPaymentData, err.Error())
+	}
+	return app.OrderID(orderID), nil
+}
+
+func writePaymentResponse(w http.ResponseWriter, payment *app.Payment) error {
+	status, err := paymentStatusToString(payment.Status)
+	if err != nil {
+		return err
+	}
+	response := paymentResponse{
+		PaymentID: string(payment.ID),
+		OrderID:   string(payment.OrderID),
+		Amount:    payment.Amount,
+		Status:    status,
+	}
+	if payment.ExpirationDate != nil {
+		response.ExpirationDate = payment.ExpirationDate.Format(time.RFC3339)
+	}
+	writeResponse(w, response)
+	return nil
+}
+
+func paymentStatusToString(status app.PaymentStatus) (string, error) {
+	switch status {
+	case app.PaymentStatusCaptured:
+		return paymentStatusCaptured, nil
+	case app.PaymentStatusFailed:
+		return paymentStatusFailed, nil
+	case app.PaymentStatusAuthorized:
+		return paymentStatusAuthorized, nil
+	case app.PaymentStatusVoided:
+		return paymentStatusVoided, nil
+	case app.PaymentStatusExpired:
+		return paymentStatusExpired, nil
+	case app.PaymentStatusRefunded:
+		return paymentStatusRefunded, nil
+	default:
+		return "", errors.Errorf("unknown payment status %d", status)
+	}
+}
+
+type paymentInfo struct {
+	OrderID string      `json:"orderId"`
+	UserID  string      `json:"userId"`
+	Amount  money.Money `json:"amount"`
+}
+
+type paymentResponse struct {
+	PaymentID      string      `json:"paymentId"`
+	OrderID        string      `json:"orderId"`
+	Amount         money.Money `json:"amount"`
+	Status         string      `json:"status"`
+	ExpirationDate string      `json:"expirationDate,omitempty"`
+}
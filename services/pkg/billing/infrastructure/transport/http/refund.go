@@ -0,0 +1,61 @@
+package http
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func (s *Server) refundPaymentEndpoint(w http.ResponseWriter, r *http.Request) error {
+	requestID, err := s.getRequestIDHeader(r)
+	if err != nil {
+		return err
+	}
+
+	var info refundInfo
+	bytesBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	_ = r.Body.Close()
+	if err = json.Unmarshal(bytesBody, &info); err != nil {
+		return err
+	}
+	if err = uuid.ValidateUUID(info.PaymentID); err != nil {
+		return errors.Wrap(errInvalidPaymentData, err.Error())
+	}
+
+	refund, err := s.billingService.RefundPayment(requestID, app.PaymentID(info.PaymentID), info.Amount)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, refundResponse{
+		RefundID:  string(refund.ID),
+		PaymentID: string(refund.PaymentID),
+		OrderID:   string(refund.OrderID),
+		Amount:    refund.Amount,
+		Timestamp: refund.CreationDate.Format(time.RFC3339),
+	})
+	return nil
+}
+
+// refundInfo Amount is omitted to refund the whole not refunded amount of the payment
+type refundInfo struct {
+	PaymentID string       `json:"paymentId"`
+	Amount    *money.Money `json:"amount,omitempty"`
+}
+
+type refundResponse struct {
+	RefundID  string      `json:"refundId"`
+	PaymentID string      `json:"paymentId"`
+	OrderID   string      `json:"orderId"`
+	Amount    money.Money `json:"amount"`
+	Timestamp string      `json:"timestamp"`
+}
@@ -2,7 +2,9 @@ package http
 
 import (
 	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/uuid"
+	"arch-homework/pkg/common/infrastructure/metrics"
 	"arch-homework/pkg/common/jwtauth"
 
 	"github.com/gorilla/mux"
@@ -13,22 +15,46 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"regexp"
+	"strings"
 )
 
 const PathPrefix = "/api/v1/"
 const PathPrefixInternal = "/internal/api/v1/"
 
 const (
-	accountEndpoint = PathPrefix + "account"
-	paymentEndpoint = PathPrefixInternal + "payment"
+	accountEndpoint              = PathPrefix + "account"
+	transactionsEndpoint         = PathPrefix + "account/transactions"
+	statementEndpoint            = PathPrefix + "account/statement"
+	transferEndpoint             = PathPrefix + "account/transfer"
+	paymentEndpoint              = PathPrefixInternal + "payment"
+	paymentAuthorizationEndpoint = PathPrefixInternal + "payment/authorization"
+	paymentCaptureEndpoint       = PathPrefixInternal + "payment/{orderId}/capture"
+	paymentVoidEndpoint          = PathPrefixInternal + "payment/{orderId}/void"
+	refundEndpoint               = PathPrefixInternal + "refund"
+	adminAccountEndpoint         = PathPrefixInternal + "account/{userId}"
+	adminCreditLimitEndpoint     = PathPrefixInternal + "account/{userId}/credit-limit"
 )
 
 const (
-	errorCodeUnknown          = 0
-	errorCodeInvalidRequestID = 1
-	errorCodeAlreadyProcessed = 2
-	errorNotEnoughFunds       = 3
-	errorInvalidAmount        = 4
+	errorCodeUnknown            = 0
+	errorCodeInvalidRequestID   = 1
+	errorCodeAlreadyProcessed   = 2
+	errorNotEnoughFunds         = 3
+	errorInvalidAmount          = 4
+	errorCurrencyMismatch       = 5
+	errorInvalidListParams      = 6
+	errorPaymentMismatch        = 7
+	errorAccountNotFound        = 8
+	errorInvalidPaymentData     = 9
+	errorPaymentNotFound        = 10
+	errorInvalidPaymentStatus   = 11
+	errorRefundMismatch         = 12
+	errorRefundExceedsPayment   = 13
+	errorInvalidUserID          = 14
+	errorInvalidStatementPeriod = 15
+	errorTransferToSelf         = 16
+	errorRecipientNotFound      = 17
 )
 
 const authTokenHeader = "X-Auth-Token"
@@ -36,6 +62,36 @@ const requestIDHeader = "X-Request-ID"
 
 var errForbidden = errors.New("access forbidden")
 var errInvalidRequestID = errors.New("empty or invalid request id")
+var errInvalidPaymentData = errors.New("invalid payment data")
+
+func NewEndpointLabelCollector() metrics.EndpointLabelCollector {
+	return endpointLabelCollector{}
+}
+
+type endpointLabelCollector struct {
+}
+
+func (e endpointLabelCollector) EndpointLabelForURI(uri string) string {
+	if strings.HasPrefix(uri, PathPrefixInternal) {
+		r, _ := regexp.Compile("^" + PathPrefixInternal + "payment/[a-f0-9-]+/capture$")
+		if r.MatchString(uri) {
+			return paymentCaptureEndpoint
+		}
+		r, _ = regexp.Compile("^" + PathPrefixInternal + "payment/[a-f0-9-]+/void$")
+		if r.MatchString(uri) {
+			return paymentVoidEndpoint
+		}
+		r, _ = regexp.Compile("^" + PathPrefixInternal + "account/[a-f0-9-]+$")
+		if r.MatchString(uri) {
+			return adminAccountEndpoint
+		}
+		r, _ = regexp.Compile("^" + PathPrefixInternal + "account/[a-f0-9-]+/credit-limit$")
+		if r.MatchString(uri) {
+			return adminCreditLimitEndpoint
+		}
+	}
+	return uri
+}
 
 func NewServer(billingService app.BillingService, billingQueryService app.BillingQueryService, tokenParser jwtauth.TokenParser, logger *logrus.Logger) *Server {
 	return &Server{
@@ -57,12 +113,21 @@ func (s *Server) MakeHandler() http.Handler {
 	router := mux.NewRouter()
 	router.Methods(http.MethodGet).Path(accountEndpoint).Handler(s.makeHandlerFunc(s.getAccountStatusEndpoint))
 	router.Methods(http.MethodPost).Path(accountEndpoint).Handler(s.makeHandlerFunc(s.topUpAccountEndpoint))
+	router.Methods(http.MethodGet).Path(transactionsEndpoint).Handler(s.makeHandlerFunc(s.getTransactionsEndpoint))
+	router.Methods(http.MethodGet).Path(statementEndpoint).Handler(s.makeHandlerFunc(s.getStatementEndpoint))
+	router.Methods(http.MethodPost).Path(transferEndpoint).Handler(s.makeHandlerFunc(s.transferEndpoint))
 	return router
 }
 
 func (s *Server) MakeInternalHandler() http.Handler {
 	router := mux.NewRouter()
 	router.Methods(http.MethodPost).Path(paymentEndpoint).Handler(s.makeHandlerFunc(s.processPaymentEndpoint))
+	router.Methods(http.MethodPost).Path(paymentAuthorizationEndpoint).Handler(s.makeHandlerFunc(s.authorizePaymentEndpoint))
+	router.Methods(http.MethodPost).Path(paymentCaptureEndpoint).Handler(s.makeHandlerFunc(s.capturePaymentEndpoint))
+	router.Methods(http.MethodPost).Path(paymentVoidEndpoint).Handler(s.makeHandlerFunc(s.voidPaymentEndpoint))
+	router.Methods(http.MethodPost).Path(refundEndpoint).Handler(s.makeHandlerFunc(s.refundPaymentEndpoint))
+	router.Methods(http.MethodGet).Path(adminAccountEndpoint).Handler(s.makeHandlerFunc(s.getAdminAccountEndpoint))
+	router.Methods(http.MethodPut).Path(adminCreditLimitEndpoint).Handler(s.makeHandlerFunc(s.setCreditLimitEndpoint))
 	return router
 }
 
@@ -111,11 +176,15 @@ func (s *Server) getAccountStatusEndpoint(w http.ResponseWriter, r *http.Request
 		return err
 	}
 
-	balance, err := s.billingQueryService.AccountBalance(app.UserID(tokenData.UserID()))
+	account, err := s.billingQueryService.Account(app.UserID(tokenData.UserID()))
+	if err != nil {
+		return err
+	}
+	response, err := toAccountStatusResponse(account)
 	if err != nil {
 		return err
 	}
-	writeResponse(w, accountStatusResponse{Amount: balance.Value()})
+	writeResponse(w, response)
 	return nil
 }
 
@@ -139,38 +208,8 @@ func (s *Server) topUpAccountEndpoint(w http.ResponseWriter, r *http.Request) er
 	if err = json.Unmarshal(bytesBody, &info); err != nil {
 		return err
 	}
-	amount, err := app.AmountFromFloat(info.Amount)
-	if err != nil {
-		return err
-	}
 
-	if err = s.billingService.TopUpAccount(requestID, app.UserID(tokenData.UserID()), amount); err != nil {
-		return err
-	}
-
-	w.WriteHeader(http.StatusOK)
-	return nil
-}
-
-func (s *Server) processPaymentEndpoint(w http.ResponseWriter, r *http.Request) error {
-	var info paymentInfo
-	bytesBody, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		return err
-	}
-	_ = r.Body.Close()
-	if err = json.Unmarshal(bytesBody, &info); err != nil {
-		return err
-	}
-	if err = uuid.ValidateUUID(info.UserID); err != nil {
-		return err
-	}
-	amount, err := app.AmountFromFloat(info.Amount)
-	if err != nil {
-		return err
-	}
-
-	if err = s.billingService.ProcessPayment(app.UserID(info.UserID), amount); err != nil {
+	if err = s.billingService.TopUpAccount(requestID, app.UserID(tokenData.UserID()), info.Amount); err != nil {
 		return err
 	}
 
@@ -219,15 +258,51 @@ func writeErrorResponse(w http.ResponseWriter, err error) {
 	case errInvalidRequestID:
 		info.Code = errorCodeInvalidRequestID
 		w.WriteHeader(http.StatusBadRequest)
-	case app.ErrAlreadyProcessed:
-		info.Code = errorCodeAlreadyProcessed
-		w.WriteHeader(http.StatusConflict)
 	case app.ErrNotEnoughFunds:
 		info.Code = errorNotEnoughFunds
 		w.WriteHeader(http.StatusBadRequest)
-	case app.ErrNegativeAmount, app.ErrNotRoundedAmount:
+	case app.ErrNegativeAmount, app.ErrNegativeCreditLimit, money.ErrInvalidAmount, money.ErrUnknownCurrency, money.ErrOverflow:
 		info.Code = errorInvalidAmount
 		w.WriteHeader(http.StatusBadRequest)
+	case money.ErrCurrencyMismatch:
+		info.Code = errorCurrencyMismatch
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case errInvalidListParam, app.ErrInvalidLedgerListSpec:
+		info.Code = errorInvalidListParams
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrPaymentMismatch:
+		info.Code = errorPaymentMismatch
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case app.ErrUserAccountNotFound:
+		info.Code = errorAccountNotFound
+		w.WriteHeader(http.StatusNotFound)
+	case app.ErrPaymentNotFound:
+		info.Code = errorPaymentNotFound
+		w.WriteHeader(http.StatusNotFound)
+	case app.ErrInvalidPaymentStatus:
+		info.Code = errorInvalidPaymentStatus
+		w.WriteHeader(http.StatusConflict)
+	case errInvalidPaymentData:
+		info.Code = errorInvalidPaymentData
+		w.WriteHeader(http.StatusBadRequest)
+	case errInvalidStatementParam, app.ErrInvalidStatementPeriod:
+		info.Code = errorInvalidStatementPeriod
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrTransferToSelf:
+		info.Code = errorTransferToSelf
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrRecipientAccountNotFound:
+		info.Code = errorRecipientNotFound
+		w.WriteHeader(http.StatusNotFound)
+	case errInvalidUserID:
+		info.Code = errorInvalidUserID
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrRefundMismatch:
+		info.Code = errorRefundMismatch
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case app.ErrRefundExceedsPayment:
+		info.Code = errorRefundExceedsPayment
+		w.WriteHeader(http.StatusUnprocessableEntity)
 	case errForbidden:
 		w.WriteHeader(http.StatusForbidden)
 	default:
@@ -242,15 +317,28 @@ type errorInfo struct {
 	Message string `json:"message"`
 }
 
-type accountStatusResponse struct {
-	Amount float64 `json:"amount"`
+func toAccountStatusResponse(account *app.UserAccount) (accountStatusResponse, error) {
+	available, err := account.Available()
+	if err != nil {
+		return accountStatusResponse{}, err
+	}
+	return accountStatusResponse{
+		Balance:     account.Amount,
+		Available:   available,
+		Held:        account.Held,
+		CreditLimit: account.CreditLimit,
+	}, nil
 }
 
-type topUpAccountInfo struct {
-	Amount float64 `json:"amount"`
+// accountStatusResponse Balance is the signed ledger balance,
+// Available is the part of it and of the credit limit not held by authorized payments
+type accountStatusResponse struct {
+	Balance     money.Money `json:"balance"`
+	Available   money.Money `json:"available"`
+	Held        money.Money `json:"held"`
+	CreditLimit money.Money `json:"creditLimit"`
 }
 
-type paymentInfo struct {
-	UserID string  `json:"userId"`
-	Amount float64 `json:"amount"`
+type topUpAccountInfo struct {
+	Amount money.Money `json:"amount"`
 }
@@ -0,0 +1,255 @@
+package http
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	statementParamFrom   = "from"
+	statementParamTo     = "to"
+	statementParamFormat = "format"
+
+	statementFormatCSV  = "csv"
+	statementFormatJSON = "json"
+
+	statementDateLayout = "2006-01-02"
+)
+
+var errInvalidStatementParam = errors.New("invalid statement parameter")
+
+var statementCSVHeader = []string{"timestamp", "type", "transaction_id", "order_id", "request_id", "amount", "balance", "currency"}
+
+const (
+	statementRowOpeningBalance = "opening_balance"
+	statementRowClosingBalance = "closing_balance"
+)
+
+// getStatementEndpoint streams the statement, error after the response is started can only be logged
+func (s *Server) getStatementEndpoint(w http.ResponseWriter, r *http.Request) error {
+	tokenData, err := s.extractAuthorizationData(r)
+	if err != nil {
+		return err
+	}
+
+	spec, format, err := parseStatementParams(r, app.UserID(tokenData.UserID()))
+	if err != nil {
+		return err
+	}
+	var writer statementWriter
+	if format == statementFormatCSV {
+		writer = newCSVStatementWriter(w)
+	} else {
+		writer = newJSONStatementWriter(w)
+	}
+
+	err = s.billingQueryService.Statement(spec, writer)
+	if err != nil && writer.Started() {
+		s.logger.WithField("path", r.URL.Path).Error(errors.Wrap(err, "statement is interrupted"))
+		return nil
+	}
+	return err
+}
+
+// parseStatementParams from and to are inclusive dates in UTC, json is the default format
+func parseStatementParams(r *http.Request, userID app.UserID) (app.StatementSpec, string, error) {
+	query := r.URL.Query()
+	spec := app.StatementSpec{UserID: userID}
+	from, err := time.Parse(statementDateLayout, query.Get(statementParamFrom))
+	if err != nil {
+		return spec, "", errors.Wrap(errInvalidStatementParam, statementParamFrom)
+	}
+	to, err := time.Parse(statementDateLayout, query.Get(statementParamTo))
+	if err != nil {
+		return spec, "", errors.Wrap(errInvalidStatementParam, statementParamTo)
+	}
+	spec.From, spec.To = from, to.AddDate(0, 0, 1)
+
+	format := query.Get(statementParamFormat)
+	switch format {
+	case "":
+		format = statementFormatJSON
+	case statementFormatCSV, statementFormatJSON:
+	default:
+		return spec, "", errors.Wrap(errInvalidStatementParam, statementParamFormat)
+	}
+	return spec, format, nil
+}
+
+type statementWriter interface {
+	app.StatementWriter
+	// Started reports whether the response has been started
+	Started() bool
+}
+
+func newCSVStatementWriter(w http.ResponseWriter) statementWriter {
+	buf := bufio.NewWriter(w)
+	return &csvStatementWriter{w: w, buf: buf, csv: csv.NewWriter(buf)}
+}
+
+type csvStatementWriter struct {
+	w       http.ResponseWriter
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	spec    app.StatementSpec
+	started bool
+}
+
+func (c *csvStatementWriter) Started() bool {
+	return c.started
+}
+
+func (c *csvStatementWriter) WriteOpeningBalance(spec app.StatementSpec, balance money.Money) error {
+	c.spec = spec
+	c.started = true
+	writeStatementHeaders(c.w, spec, "text/csv;charset=UTF-8", statementFormatCSV)
+	if err := c.csv.Write(statementCSVHeader); err != nil {
+		return errors.WithStack(err)
+	}
+	return c.writeBalance(spec.From, statementRowOpeningBalance, balance)
+}
+
+func (c *csvStatementWriter) WriteMovement(movement app.StatementMovement) error {
+	var orderID, requestID string
+	if movement.OrderID != nil {
+		orderID = string(*movement.OrderID)
+	}
+	if movement.RequestID != nil {
+		requestID = string(*movement.RequestID)
+	}
+	err := c.csv.Write([]string{
+		movement.CreationDate.Format(time.RFC3339Nano),
+		string(movement.Type),
+		string(movement.TransactionID),
+		orderID,
+		requestID,
+		movement.Amount.Decimal(),
+		movement.Balance.Decimal(),
+		string(movement.Amount.Currency()),
+	})
+	return errors.WithStack(err)
+}
+
+func (c *csvStatementWriter) WriteClosingBalance(balance money.Money) error {
+	if err := c.writeBalance(c.spec.To, statementRowClosingBalance, balance); err != nil {
+		return err
+	}
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(c.buf.Flush())
+}
+
+func (c *csvStatementWriter) writeBalance(date time.Time, rowType string, balance money.Money) error {
+	err := c.csv.Write([]string{
+		date.Format(time.RFC3339Nano),
+		rowType,
+		"",
+		"",
+		"",
+		"",
+		balance.Decimal(),
+		string(balance.Currency()),
+	})
+	return errors.WithStack(err)
+}
+
+// jsonStatementWriter writes the statement object part by part, movements are written as they are read
+func newJSONStatementWriter(w http.ResponseWriter) statementWriter {
+	return &jsonStatementWriter{w: w, buf: bufio.NewWriter(w)}
+}
+
+type jsonStatementWriter struct {
+	w            http.ResponseWriter
+	buf          *bufio.Writer
+	hasMovements bool
+	started      bool
+}
+
+func (j *jsonStatementWriter) Started() bool {
+	return j.started
+}
+
+func (j *jsonStatementWriter) WriteOpeningBalance(spec app.StatementSpec, balance money.Money) error {
+	j.started = true
+	writeStatementHeaders(j.w, spec, "application/json;charset=UTF-8", statementFormatJSON)
+	opening, err := json.Marshal(balance)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	from, to := statementDates(spec)
+	_, err = fmt.Fprintf(j.buf, `{"from":"%s","to":"%s","openingBalance":%s,"movements":[`, from, to, opening)
+	return errors.WithStack(err)
+}
+
+func (j *jsonStatementWriter) WriteMovement(movement app.StatementMovement) error {
+	info := statementMovementInfo{
+		TransactionID: string(movement.TransactionID),
+		Type:          string(movement.Type),
+		Amount:        movement.Amount,
+		Balance:       movement.Balance,
+		Timestamp:     movement.CreationDate.Format(time.RFC3339Nano),
+	}
+	if movement.RequestID != nil {
+		info.RequestID = string(*movement.RequestID)
+	}
+	if movement.OrderID != nil {
+		info.OrderID = string(*movement.OrderID)
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if j.hasMovements {
+		if err = j.buf.WriteByte(','); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	j.hasMovements = true
+	_, err = j.buf.Write(data)
+	return errors.WithStack(err)
+}
+
+func (j *jsonStatementWriter) WriteClosingBalance(balance money.Money) error {
+	closing, err := json.Marshal(balance)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err = fmt.Fprintf(j.buf, `],"closingBalance":%s}`, closing); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(j.buf.Flush())
+}
+
+func writeStatementHeaders(w http.ResponseWriter, spec app.StatementSpec, contentType string, extension string) {
+	from, to := statementDates(spec)
+	fileName := fmt.Sprintf("statement-%s-%s.%s", from, to, extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	w.WriteHeader(http.StatusOK)
+}
+
+// statementDates returns inclusive dates of the period as they were requested
+func statementDates(spec app.StatementSpec) (string, string) {
+	return spec.From.Format(statementDateLayout), spec.To.AddDate(0, 0, -1).Format(statementDateLayout)
+}
+
+type statementMovementInfo struct {
+	TransactionID string      `json:"transactionId"`
+	Type          string      `json:"type"`
+	Amount        money.Money `json:"amount"`
+	Balance       money.Money `json:"balance"`
+	RequestID     string      `json:"requestId,omitempty"`
+	OrderID       string      `json:"orderId,omitempty"`
+	Timestamp     string      `json:"timestamp"`
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	listParamLimit  = "limit"
+	listParamCursor = "cursor"
+)
+
+var errInvalidListParam = errors.New("invalid transaction list parameter")
+
+func (s *Server) getTransactionsEndpoint(w http.ResponseWriter, r *http.Request) error {
+	tokenData, err := s.extractAuthorizationData(r)
+	if err != nil {
+		return err
+	}
+
+	spec, err := parseLedgerListSpec(r, app.UserID(tokenData.UserID()))
+	if err != nil {
+		return err
+	}
+	page, err := s.billingQueryService.Transactions(spec)
+	if err != nil {
+		return err
+	}
+	infos := make([]transactionInfo, 0, len(page.Transactions))
+	for _, transaction := range page.Transactions {
+		info, err := toTransactionInfo(transaction)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, info)
+	}
+	writeResponse(w, transactionListResponse{
+		Transactions: infos,
+		NextCursor:   encodeCursor(page.NextCursor),
+	})
+	return nil
+}
+
+func parseLedgerListSpec(r *http.Request, userID app.UserID) (app.LedgerListSpec, error) {
+	query := r.URL.Query()
+	spec := app.LedgerListSpec{
+		UserID: userID,
+		Limit:  app.DefaultLedgerPageSize,
+	}
+	if value := query.Get(listParamLimit); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return spec, errors.Wrap(errInvalidListParam, listParamLimit)
+		}
+		spec.Limit = limit
+	}
+	if value := query.Get(listParamCursor); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			return spec, errors.Wrap(errInvalidListParam, listParamCursor)
+		}
+		spec.Cursor = cursor
+	}
+	return spec, nil
+}
+
+func toTransactionInfo(transaction app.LedgerTransaction) (transactionInfo, error) {
+	amount, err := transaction.UserAmount()
+	if err != nil {
+		return transactionInfo{}, err
+	}
+	info := transactionInfo{
+		ID:        string(transaction.ID),
+		Type:      string(transaction.Type),
+		Amount:    amount,
+		Timestamp: transaction.CreationDate.Format(time.RFC3339Nano),
+	}
+	if transaction.RequestID != nil {
+		info.RequestID = string(*transaction.RequestID)
+	}
+	if transaction.OrderID != nil {
+		info.OrderID = string(*transaction.OrderID)
+	}
+	return info, nil
+}
+
+func encodeCursor(cursor *app.LedgerCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	data, _ := json.Marshal(cursorView{
+		CreationDate: cursor.CreationDate.Format(time.RFC3339Nano),
+		ID:           string(cursor.ID),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*app.LedgerCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var view cursorView
+	if err = json.Unmarshal(data, &view); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = uuid.ValidateUUID(view.ID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	creationDate, err := time.Parse(time.RFC3339Nano, view.CreationDate)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &app.LedgerCursor{
+		CreationDate: creationDate,
+		ID:           app.LedgerTransactionID(view.ID),
+	}, nil
+}
+
+type cursorView struct {
+	CreationDate string `json:"c"`
+	ID           string `json:"id"`
+}
+
+type transactionInfo struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Amount    money.Money `json:"amount"`
+	RequestID string      `json:"requestId,omitempty"`
+	OrderID   string      `json:"orderId,omitempty"`
+	Timestamp string      `json:"timestamp"`
+}
+
+type transactionListResponse struct {
+	Transactions []transactionInfo `json:"transactions"`
+	NextCursor   string            `json:"nextCursor,omitempty"`
+}
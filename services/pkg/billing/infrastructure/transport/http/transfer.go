@@ -0,0 +1,56 @@
+package http
+
+import (
+	"arch-homework/pkg/billing/app"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+func (s *Server) transferEndpoint(w http.ResponseWriter, r *http.Request) error {
+	tokenData, err := s.extractAuthorizationData(r)
+	if err != nil {
+		return err
+	}
+
+	requestID, err := s.getRequestIDHeader(r)
+	if err != nil {
+		return err
+	}
+
+	var info transferInfo
+	bytesBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	_ = r.Body.Close()
+	if err = json.Unmarshal(bytesBody, &info); err != nil {
+		return err
+	}
+	if err = uuid.ValidateUUID(info.RecipientID); err != nil {
+		return errors.Wrap(errInvalidUserID, err.Error())
+	}
+
+	err = s.billingService.TransferFunds(app.Transfer{
+		RequestID:   requestID,
+		SenderID:    app.UserID(tokenData.UserID()),
+		RecipientID: app.UserID(info.RecipientID),
+		Amount:      info.Amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+type transferInfo struct {
+	RecipientID string      `json:"recipientId"`
+	Amount      money.Money `json:"amount"`
+}
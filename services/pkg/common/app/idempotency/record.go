@@ -0,0 +1,38 @@
+package idempotency
+
+import (
+	"arch-homework/pkg/common/app/uuid"
+	"time"
+)
+
+type RequestID uuid.UUID
+
+// Key identifies request, the same request id sent by different users are different keys
+type Key struct {
+	RequestID RequestID
+	UserID    string
+}
+
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+type Record struct {
+	Key         Key
+	RequestHash string
+	// Response is nil while the request is still processed
+	Response *Response
+	// CreatedAt is reset when the record without response is taken over
+	CreatedAt time.Time
+}
+
+type Repository interface {
+	// Create stores new record without response, if record with the same key exists it is returned instead.
+	// Record of the same request left without response for lockTimeout is taken over as its request is lost
+	Create(key Key, requestHash string, lockTimeout time.Duration) (existing *Record, err error)
+	SaveResponse(key Key, response Response) error
+	Remove(key Key) error
+	RemoveCreatedBefore(time time.Time) (removed int64, err error)
+}
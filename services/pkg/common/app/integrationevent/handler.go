@@ -1,5 +1,11 @@
 package integrationevent
 
+import "github.com/pkg/errors"
+
+// ErrEventSkipped is returned by the handler for the event which can not be applied and must not be retried,
+// e.g. the event about the entity unknown to the service
+var ErrEventSkipped = errors.New("integration event skipped")
+
 type EventHandler interface {
 	Handle(event EventData) error
 }
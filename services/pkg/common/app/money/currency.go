@@ -0,0 +1,57 @@
+package money
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Currency is ISO 4217 alphabetic currency code
+type Currency string
+
+// currencyExponents holds number of minor unit digits of supported currencies
+var currencyExponents = map[Currency]int{
+	"AED": 2,
+	"AMD": 2,
+	"AUD": 2,
+	"BYN": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CNY": 2,
+	"CZK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"GEL": 2,
+	"HKD": 2,
+	"INR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"KZT": 2,
+	"NOK": 2,
+	"PLN": 2,
+	"RUB": 2,
+	"SEK": 2,
+	"TRY": 2,
+	"UAH": 2,
+	"USD": 2,
+}
+
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(code))
+	if _, ok := currencyExponents[currency]; !ok {
+		return "", errors.Wrap(ErrUnknownCurrency, code)
+	}
+	return currency, nil
+}
+
+// Exponent returns number of digits after the decimal separator
+func (c Currency) Exponent() int {
+	return currencyExponents[c]
+}
+
+func (c Currency) String() string {
+	return string(c)
+}
@@ -0,0 +1,207 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidAmount = errors.New("invalid money amount")
+var ErrOverflow = errors.New("money amount overflow")
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+var decimalRegexp = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// Money is an exact amount in minor units of the currency
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+func New(minorUnits int64, currency Currency) Money {
+	return Money{amount: minorUnits, currency: currency}
+}
+
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// Parse parses decimal amount like "12.30", fractional part can not be longer than currency exponent
+func Parse(amount string, currency Currency) (Money, error) {
+	if _, ok := currencyExponents[currency]; !ok {
+		return Money{}, errors.Wrap(ErrUnknownCurrency, string(currency))
+	}
+	if !decimalRegexp.MatchString(amount) {
+		return Money{}, errors.Wrap(ErrInvalidAmount, amount)
+	}
+	integerPart, fractionalPart := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		integerPart, fractionalPart = amount[:i], amount[i+1:]
+	}
+	exponent := currency.Exponent()
+	fractionalPart = strings.TrimRight(fractionalPart, "0")
+	if len(fractionalPart) > exponent {
+		return Money{}, errors.Wrapf(ErrInvalidAmount, "%s has more than %d fractional digits", amount, exponent)
+	}
+	fractionalPart += strings.Repeat("0", exponent-len(fractionalPart))
+
+	value, err := strconv.ParseInt(integerPart+fractionalPart, 10, 64)
+	if err != nil {
+		return Money{}, errors.Wrap(ErrOverflow, amount)
+	}
+	return Money{amount: value, currency: currency}, nil
+}
+
+// MinorUnits returns amount in minor units, e.g. cents
+func (m Money) MinorUnits() int64 {
+	return m.amount
+}
+
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	if (other.amount > 0 && m.amount > math.MaxInt64-other.amount) ||
+		(other.amount < 0 && m.amount < math.MinInt64-other.amount) {
+		return Money{}, errors.WithStack(ErrOverflow)
+	}
+	return Money{amount: m.amount + other.amount, currency: m.currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.amount == math.MinInt64 {
+		return Money{}, errors.WithStack(ErrOverflow)
+	}
+	return m.Add(Money{amount: -other.amount, currency: other.currency})
+}
+
+func (m Money) Mul(factor uint64) (Money, error) {
+	if factor == 0 || m.amount == 0 {
+		return Money{currency: m.currency}, nil
+	}
+	if factor > math.MaxInt64 {
+		return Money{}, errors.WithStack(ErrOverflow)
+	}
+	f := int64(factor)
+	if m.amount > math.MaxInt64/f || m.amount < math.MinInt64/f {
+		return Money{}, errors.WithStack(ErrOverflow)
+	}
+	return Money{amount: m.amount * f, currency: m.currency}, nil
+}
+
+// Percentage returns percent of the amount rounded towards zero
+func (m Money) Percentage(percent uint64) (Money, error) {
+	if percent > math.MaxInt64/100 {
+		return Money{}, errors.WithStack(ErrOverflow)
+	}
+	p := int64(percent)
+	whole, err := Money{amount: m.amount / 100, currency: m.currency}.Mul(percent)
+	if err != nil {
+		return Money{}, err
+	}
+	return whole.Add(Money{amount: m.amount % 100 * p / 100, currency: m.currency})
+}
+
+// Cmp returns -1, 0 or 1 if m is less than, equal to or greater than other
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < other.amount:
+		return -1, nil
+	case m.amount > other.amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Decimal returns exact decimal representation of amount without currency, e.g. "12.30"
+func (m Money) Decimal() string {
+	var abs uint64
+	sign := ""
+	if m.amount < 0 {
+		sign = "-"
+		abs = uint64(-(m.amount + 1)) + 1
+	} else {
+		abs = uint64(m.amount)
+	}
+	digits := strconv.FormatUint(abs, 10)
+	exponent := m.currency.Exponent()
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.currency)
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if m.currency != other.currency {
+		return errors.Wrapf(ErrCurrencyMismatch, "%s and %s", m.currency, other.currency)
+	}
+	return nil
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount, _ := json.Marshal(m.Decimal())
+	return json.Marshal(jsonMoney{
+		Amount:   amount,
+		Currency: string(m.currency),
+	})
+}
+
+// UnmarshalJSON accepts amount both as decimal string and as number literal, the literal is never converted to float
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var value jsonMoney
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.Wrap(ErrInvalidAmount, err.Error())
+	}
+	currency, err := ParseCurrency(value.Currency)
+	if err != nil {
+		return err
+	}
+	amount := string(value.Amount)
+	if strings.HasPrefix(amount, `"`) {
+		if err = json.Unmarshal(value.Amount, &amount); err != nil {
+			return errors.Wrap(ErrInvalidAmount, err.Error())
+		}
+	}
+	res, err := Parse(amount, currency)
+	if err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
+
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
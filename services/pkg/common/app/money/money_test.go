@@ -0,0 +1,311 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	usd Currency = "USD"
+	eur Currency = "EUR"
+	jpy Currency = "JPY"
+	kwd Currency = "KWD"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		amount      string
+		currency    Currency
+		expected    int64
+		expectedErr error
+	}{
+		{amount: "12.30", currency: usd, expected: 1230},
+		{amount: "12.3", currency: usd, expected: 1230},
+		{amount: "12", currency: usd, expected: 1200},
+		{amount: "12.300", currency: usd, expected: 1230},
+		{amount: "-5.01", currency: usd, expected: -501},
+		{amount: "0", currency: usd, expected: 0},
+		{amount: "1500", currency: jpy, expected: 1500},
+		{amount: "1.234", currency: kwd, expected: 1234},
+		{amount: "92233720368547758.07", currency: usd, expected: math.MaxInt64},
+		{amount: "-92233720368547758.08", currency: usd, expected: math.MinInt64},
+		{amount: "0.001", currency: usd, expectedErr: ErrInvalidAmount},
+		{amount: "1.5", currency: jpy, expectedErr: ErrInvalidAmount},
+		{amount: "", currency: usd, expectedErr: ErrInvalidAmount},
+		{amount: "1.", currency: usd, expectedErr: ErrInvalidAmount},
+		{amount: ".5", currency: usd, expectedErr: ErrInvalidAmount},
+		{amount: "1e3", currency: usd, expectedErr: ErrInvalidAmount},
+		{amount: "+1", currency: usd, expectedErr: ErrInvalidAmount},
+		{amount: "92233720368547758.08", currency: usd, expectedErr: ErrOverflow},
+		{amount: "1", currency: "XXX", expectedErr: ErrUnknownCurrency},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.amount+" "+string(testCase.currency), func(t *testing.T) {
+			m, err := Parse(testCase.amount, testCase.currency)
+			if errors.Cause(err) != testCase.expectedErr {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+			if err == nil && (m.MinorUnits() != testCase.expected || m.Currency() != testCase.currency) {
+				t.Errorf("expected %d %s, got %d %s", testCase.expected, testCase.currency, m.MinorUnits(), m.Currency())
+			}
+		})
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	testCases := []struct {
+		name        string
+		a           Money
+		b           Money
+		expectedAdd Money
+		expectedSub Money
+		addErr      error
+		subErr      error
+	}{
+		{
+			name:        "positive amounts",
+			a:           New(1230, usd),
+			b:           New(70, usd),
+			expectedAdd: New(1300, usd),
+			expectedSub: New(1160, usd),
+		},
+		{
+			name:        "negative result",
+			a:           New(100, usd),
+			b:           New(250, usd),
+			expectedAdd: New(350, usd),
+			expectedSub: New(-150, usd),
+		},
+		{
+			name:        "max amount",
+			a:           New(math.MaxInt64, usd),
+			b:           New(1, usd),
+			addErr:      ErrOverflow,
+			expectedSub: New(math.MaxInt64-1, usd),
+		},
+		{
+			name:        "min amount",
+			a:           New(math.MinInt64, usd),
+			b:           New(1, usd),
+			expectedAdd: New(math.MinInt64+1, usd),
+			subErr:      ErrOverflow,
+		},
+		{
+			name:        "subtract min amount",
+			a:           New(0, usd),
+			b:           New(math.MinInt64, usd),
+			expectedAdd: New(math.MinInt64, usd),
+			subErr:      ErrOverflow,
+		},
+		{
+			name:   "currency mismatch",
+			a:      New(100, usd),
+			b:      New(100, eur),
+			addErr: ErrCurrencyMismatch,
+			subErr: ErrCurrencyMismatch,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			sum, err := testCase.a.Add(testCase.b)
+			if errors.Cause(err) != testCase.addErr {
+				t.Errorf("expected add error %v, got %v", testCase.addErr, err)
+			} else if err == nil && sum != testCase.expectedAdd {
+				t.Errorf("expected sum %s, got %s", testCase.expectedAdd, sum)
+			}
+			diff, err := testCase.a.Sub(testCase.b)
+			if errors.Cause(err) != testCase.subErr {
+				t.Errorf("expected sub error %v, got %v", testCase.subErr, err)
+			} else if err == nil && diff != testCase.expectedSub {
+				t.Errorf("expected difference %s, got %s", testCase.expectedSub, diff)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	testCases := []struct {
+		name        string
+		m           Money
+		factor      uint64
+		expected    Money
+		expectedErr error
+	}{
+		{name: "positive", m: New(1230, usd), factor: 3, expected: New(3690, usd)},
+		{name: "negative", m: New(-1230, usd), factor: 3, expected: New(-3690, usd)},
+		{name: "zero factor", m: New(1230, usd), factor: 0, expected: Zero(usd)},
+		{name: "zero amount", m: Zero(usd), factor: math.MaxUint64, expected: Zero(usd)},
+		{name: "overflow", m: New(math.MaxInt64/2+1, usd), factor: 2, expectedErr: ErrOverflow},
+		{name: "negative overflow", m: New(math.MinInt64/2-1, usd), factor: 2, expectedErr: ErrOverflow},
+		{name: "factor overflow", m: New(1, usd), factor: math.MaxInt64 + 1, expectedErr: ErrOverflow},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			res, err := testCase.m.Mul(testCase.factor)
+			if errors.Cause(err) != testCase.expectedErr {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+			if err == nil && res != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, res)
+			}
+		})
+	}
+}
+
+func TestPercentage(t *testing.T) {
+	testCases := []struct {
+		name        string
+		m           Money
+		percent     uint64
+		expected    Money
+		expectedErr error
+	}{
+		{name: "exact", m: New(1000, usd), percent: 15, expected: New(150, usd)},
+		{name: "rounded towards zero", m: New(999, usd), percent: 10, expected: New(99, usd)},
+		{name: "negative rounded towards zero", m: New(-999, usd), percent: 10, expected: New(-99, usd)},
+		{name: "whole amount", m: New(12345, usd), percent: 100, expected: New(12345, usd)},
+		{name: "zero percent", m: New(12345, usd), percent: 0, expected: Zero(usd)},
+		{name: "max amount", m: New(math.MaxInt64, usd), percent: 50, expected: New(math.MaxInt64/2, usd)},
+		{name: "overflow", m: New(math.MaxInt64, usd), percent: 200, expectedErr: ErrOverflow},
+		{name: "percent overflow", m: New(1, usd), percent: math.MaxInt64, expectedErr: ErrOverflow},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			res, err := testCase.m.Percentage(testCase.percent)
+			if errors.Cause(err) != testCase.expectedErr {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+			if err == nil && res != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, res)
+			}
+		})
+	}
+}
+
+func TestCmp(t *testing.T) {
+	testCases := []struct {
+		name        string
+		a           Money
+		b           Money
+		expected    int
+		expectedErr error
+	}{
+		{name: "less", a: New(-1, usd), b: New(1, usd), expected: -1},
+		{name: "equal", a: New(100, usd), b: New(100, usd), expected: 0},
+		{name: "greater", a: New(math.MaxInt64, usd), b: New(math.MinInt64, usd), expected: 1},
+		{name: "currency mismatch", a: New(100, usd), b: New(100, eur), expectedErr: ErrCurrencyMismatch},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			res, err := testCase.a.Cmp(testCase.b)
+			if errors.Cause(err) != testCase.expectedErr {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+			if res != testCase.expected {
+				t.Errorf("expected %d, got %d", testCase.expected, res)
+			}
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	testCases := []struct {
+		m        Money
+		expected string
+	}{
+		{m: Zero(usd), expected: "0.00"},
+		{m: New(5, usd), expected: "0.05"},
+		{m: New(-5, usd), expected: "-0.05"},
+		{m: New(1230, usd), expected: "12.30"},
+		{m: New(-1230, usd), expected: "-12.30"},
+		{m: New(1500, jpy), expected: "1500"},
+		{m: New(1, kwd), expected: "0.001"},
+		{m: New(math.MaxInt64, usd), expected: "92233720368547758.07"},
+		{m: New(math.MinInt64, usd), expected: "-92233720368547758.08"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expected, func(t *testing.T) {
+			if res := testCase.m.Decimal(); res != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, res)
+			}
+		})
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	testCases := []Money{
+		Zero(usd),
+		New(1230, usd),
+		New(-5, usd),
+		New(1500, jpy),
+		New(1234, kwd),
+		New(math.MaxInt64, usd),
+		New(math.MinInt64, usd),
+	}
+
+	for _, m := range testCases {
+		t.Run(m.String(), func(t *testing.T) {
+			data, err := json.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res Money
+			if err = json.Unmarshal(data, &res); err != nil {
+				t.Fatal(err)
+			}
+			if res != m {
+				t.Errorf("expected %s after round trip of %s, got %s", m, data, res)
+			}
+		})
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(New(1230, usd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"amount":"12.30","currency":"USD"}`; string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		data        string
+		expected    Money
+		expectedErr error
+	}{
+		{data: `{"amount":"12.30","currency":"USD"}`, expected: New(1230, usd)},
+		{data: `{"amount":12.3,"currency":"USD"}`, expected: New(1230, usd)},
+		{data: `{"amount":92233720368547758.07,"currency":"USD"}`, expected: New(math.MaxInt64, usd)},
+		{data: `{"amount":"12.30","currency":"usd"}`, expected: New(1230, usd)},
+		{data: `{"amount":1.2e3,"currency":"USD"}`, expectedErr: ErrInvalidAmount},
+		{data: `{"amount":"12.345","currency":"USD"}`, expectedErr: ErrInvalidAmount},
+		{data: `{"amount":"12.30","currency":"XXX"}`, expectedErr: ErrUnknownCurrency},
+		{data: `{"amount":"12.30"}`, expectedErr: ErrUnknownCurrency},
+		{data: `"12.30"`, expectedErr: ErrInvalidAmount},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.data, func(t *testing.T) {
+			var res Money
+			err := json.Unmarshal([]byte(testCase.data), &res)
+			if errors.Cause(err) != testCase.expectedErr {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+			if err == nil && res != testCase.expected {
+				t.Errorf("expected %s, got %s", testCase.expected, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package idempotency
+
+import (
+	"arch-homework/pkg/common/app/idempotency"
+	"arch-homework/pkg/common/app/uuid"
+	"arch-homework/pkg/common/jwtauth"
+
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	keyLifetime     = time.Hour * 24
+	cleanupInterval = time.Hour
+	// lockTimeout exceeds server write timeout, so the request still in progress is not taken over by its retry
+	lockTimeout = time.Minute * 2
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	authTokenHeader = "X-Auth-Token"
+	replayedHeader  = "Idempotent-Replayed"
+)
+
+const (
+	// errorCodeRequestInProgress matches the code services used for already processed requests
+	errorCodeRequestInProgress = 2
+	// errorCodeRequestIDReused is out of range of service error codes
+	errorCodeRequestIDReused = 100
+)
+
+// UserIDExtractor returns id of request author, requests without author are not handled by middleware
+type UserIDExtractor func(r *http.Request) (userID string, ok bool)
+
+func NewTokenUserIDExtractor(tokenParser jwtauth.TokenParser) UserIDExtractor {
+	return func(r *http.Request) (string, bool) {
+		tokenData, err := tokenParser.ParseToken(r.Header.Get(authTokenHeader))
+		if err != nil {
+			return "", false
+		}
+		return tokenData.UserID(), true
+	}
+}
+
+// NewMiddleware returns middleware storing responses of requests with X-Request-ID header
+// and replaying them when the request is retried
+func NewMiddleware(
+	ctx context.Context,
+	repo idempotency.Repository,
+	userIDExtractor UserIDExtractor,
+	logger *logrus.Logger,
+) func(http.Handler) http.Handler {
+	m := &middleware{
+		repo:            repo,
+		userIDExtractor: userIDExtractor,
+		logger:          logger,
+	}
+	m.startCleanup(ctx)
+	return m.wrap
+}
+
+type middleware struct {
+	repo            idempotency.Repository
+	userIDExtractor UserIDExtractor
+	logger          *logrus.Logger
+}
+
+func (m *middleware) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := m.requestKey(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = ioutil.ReadAll(r.Body)
+			_ = r.Body.Close()
+			if err != nil {
+				m.logger.Error(err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+		}
+		requestHash := hashRequest(r, body)
+
+		existing, err := m.repo.Create(key, requestHash, lockTimeout)
+		if err != nil {
+			m.logger.Error(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if existing != nil {
+			m.handleExisting(w, existing, requestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode >= http.StatusInternalServerError {
+			// server errors are not final, the client should be able to retry
+			err = m.repo.Remove(key)
+		} else {
+			err = m.repo.SaveResponse(key, idempotency.Response{
+				StatusCode:  recorder.statusCode,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+			if err != nil {
+				m.logger.Error(err)
+				// retries are not replayed then, but they are not rejected as in progress either
+				err = m.repo.Remove(key)
+			}
+		}
+		if err != nil {
+			m.logger.Error(err)
+		}
+	})
+}
+
+func (m *middleware) requestKey(r *http.Request) (idempotency.Key, bool) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return idempotency.Key{}, false
+	}
+	requestID := r.Header.Get(requestIDHeader)
+	if requestID == "" || uuid.ValidateUUID(requestID) != nil {
+		return idempotency.Key{}, false
+	}
+	userID, ok := m.userIDExtractor(r)
+	if !ok {
+		return idempotency.Key{}, false
+	}
+	return idempotency.Key{RequestID: idempotency.RequestID(requestID), UserID: userID}, true
+}
+
+func (m *middleware) handleExisting(w http.ResponseWriter, existing *idempotency.Record, requestHash string) {
+	if existing.RequestHash != requestHash {
+		writeError(w, http.StatusUnprocessableEntity, errorCodeRequestIDReused, "request id is already used for another request")
+		return
+	}
+	if existing.Response == nil {
+		writeError(w, http.StatusConflict, errorCodeRequestInProgress, "request with the same id is in progress")
+		return
+	}
+
+	if existing.Response.ContentType != "" {
+		w.Header().Set("Content-Type", existing.Response.ContentType)
+	}
+	w.Header().Set(replayedHeader, "true")
+	w.WriteHeader(existing.Response.StatusCode)
+	_, _ = w.Write(existing.Response.Body)
+}
+
+func (m *middleware) startCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			case <-ticker.C:
+				removed, err := m.repo.RemoveCreatedBefore(time.Now().Add(-keyLifetime))
+				if err != nil {
+					m.logger.Error(err)
+				} else if removed > 0 {
+					m.logger.Infof("removed %d expired idempotency keys", removed)
+				}
+			}
+		}
+	}()
+}
+
+func hashRequest(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.RequestURI()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code int, message string) {
+	js, _ := json.Marshal(errorInfo{Code: code, Message: message})
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(js)
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+type errorInfo struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
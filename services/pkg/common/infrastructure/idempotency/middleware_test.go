@@ -0,0 +1,328 @@
+package idempotency
+
+import (
+	"arch-homework/pkg/common/app/idempotency"
+	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const testUserIDHeader = "X-Test-User-ID"
+
+func TestHashRequest(t *testing.T) {
+	base := hashRequest(httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil), []byte("1"))
+	testCases := []struct {
+		name         string
+		method       string
+		target       string
+		body         string
+		expectedSame bool
+	}{
+		{name: "same request", method: http.MethodPost, target: "/api/v1/orders", body: "1", expectedSame: true},
+		{name: "host is ignored", method: http.MethodPost, target: "http://other.host/api/v1/orders", body: "1", expectedSame: true},
+		{name: "another method", method: http.MethodPut, target: "/api/v1/orders", body: "1"},
+		{name: "another path", method: http.MethodPost, target: "/api/v1/order", body: "1"},
+		{name: "another query", method: http.MethodPost, target: "/api/v1/orders?id=1", body: "1"},
+		{name: "another body", method: http.MethodPost, target: "/api/v1/orders", body: "2"},
+		{name: "empty body", method: http.MethodPost, target: "/api/v1/orders"},
+		{name: "body moved to path", method: http.MethodPost, target: "/api/v1/orders1"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest(testCase.method, testCase.target, nil)
+			hash := hashRequest(r, []byte(testCase.body))
+			if (hash == base) != testCase.expectedSame {
+				t.Errorf("expected same hash %v, got %s and %s", testCase.expectedSame, base, hash)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	const requestBody = `{"amount":"10.00"}`
+	const userID = "user"
+	requestID := string(uuid.GenerateNew())
+	key := idempotency.Key{RequestID: idempotency.RequestID(requestID), UserID: userID}
+	requestHash := hashRequest(httptest.NewRequest(http.MethodPost, "/api/v1/pay", nil), []byte(requestBody))
+
+	testCases := []struct {
+		name              string
+		existing          *idempotency.Record
+		method            string
+		requestID         string
+		userID            string
+		body              string
+		handlerStatusCode int
+		expectedStatus    int
+		expectedErrorCode int
+		expectedHandled   bool
+		expectedReplayed  bool
+		// expectedResponse is the response stored after the request, nil if no record is left
+		expectedResponse *idempotency.Response
+	}{
+		{
+			name:              "new request",
+			handlerStatusCode: http.StatusCreated,
+			expectedStatus:    http.StatusCreated,
+			expectedHandled:   true,
+			expectedResponse:  &idempotency.Response{StatusCode: http.StatusCreated, ContentType: "application/json", Body: []byte(`{"ok":true}`)},
+		},
+		{
+			name:              "client error is stored",
+			handlerStatusCode: http.StatusBadRequest,
+			expectedStatus:    http.StatusBadRequest,
+			expectedHandled:   true,
+			expectedResponse:  &idempotency.Response{StatusCode: http.StatusBadRequest, ContentType: "application/json", Body: []byte(`{"ok":true}`)},
+		},
+		{
+			name:              "server error is removed",
+			handlerStatusCode: http.StatusInternalServerError,
+			expectedStatus:    http.StatusInternalServerError,
+			expectedHandled:   true,
+		},
+		{
+			name: "completed request is replayed",
+			existing: &idempotency.Record{
+				Key:         key,
+				RequestHash: requestHash,
+				Response:    &idempotency.Response{StatusCode: http.StatusCreated, ContentType: "application/json", Body: []byte(`{"stored":true}`)},
+				CreatedAt:   time.Now().Add(-time.Hour),
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedReplayed: true,
+			expectedResponse: &idempotency.Response{StatusCode: http.StatusCreated, ContentType: "application/json", Body: []byte(`{"stored":true}`)},
+		},
+		{
+			name: "request id reused with another body",
+			existing: &idempotency.Record{
+				Key:         key,
+				RequestHash: requestHash,
+				Response:    &idempotency.Response{StatusCode: http.StatusCreated},
+				CreatedAt:   time.Now(),
+			},
+			body:              `{"amount":"20.00"}`,
+			expectedStatus:    http.StatusUnprocessableEntity,
+			expectedErrorCode: errorCodeRequestIDReused,
+			expectedResponse:  &idempotency.Response{StatusCode: http.StatusCreated},
+		},
+		{
+			name: "request in progress",
+			existing: &idempotency.Record{
+				Key:         key,
+				RequestHash: requestHash,
+				CreatedAt:   time.Now(),
+			},
+			expectedStatus:    http.StatusConflict,
+			expectedErrorCode: errorCodeRequestInProgress,
+		},
+		{
+			name: "lost request is taken over",
+			existing: &idempotency.Record{
+				Key:         key,
+				RequestHash: requestHash,
+				CreatedAt:   time.Now().Add(-lockTimeout - time.Second),
+			},
+			handlerStatusCode: http.StatusOK,
+			expectedStatus:    http.StatusOK,
+			expectedHandled:   true,
+			expectedResponse:  &idempotency.Response{StatusCode: http.StatusOK, ContentType: "application/json", Body: []byte(`{"ok":true}`)},
+		},
+		{
+			name: "lost request is not taken over by another request",
+			existing: &idempotency.Record{
+				Key:         key,
+				RequestHash: requestHash,
+				CreatedAt:   time.Now().Add(-lockTimeout - time.Second),
+			},
+			body:              `{"amount":"20.00"}`,
+			expectedStatus:    http.StatusUnprocessableEntity,
+			expectedErrorCode: errorCodeRequestIDReused,
+		},
+		{
+			name:              "request of another user",
+			existing:          &idempotency.Record{Key: key, RequestHash: requestHash, CreatedAt: time.Now()},
+			userID:            "another user",
+			handlerStatusCode: http.StatusOK,
+			expectedStatus:    http.StatusOK,
+			expectedHandled:   true,
+		},
+		{
+			name:              "get request is not handled",
+			existing:          &idempotency.Record{Key: key, RequestHash: requestHash, CreatedAt: time.Now()},
+			method:            http.MethodGet,
+			handlerStatusCode: http.StatusOK,
+			expectedStatus:    http.StatusOK,
+			expectedHandled:   true,
+		},
+		{
+			name:              "invalid request id is not handled",
+			requestID:         "not uuid",
+			handlerStatusCode: http.StatusOK,
+			expectedStatus:    http.StatusOK,
+			expectedHandled:   true,
+		},
+		{
+			name:              "anonymous request is not handled",
+			userID:            "-",
+			handlerStatusCode: http.StatusOK,
+			expectedStatus:    http.StatusOK,
+			expectedHandled:   true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			if testCase.existing != nil {
+				repo.records[testCase.existing.Key] = *testCase.existing
+			}
+			handled := false
+			handler := newTestMiddleware(repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handled = true
+				if body, _ := ioutil.ReadAll(r.Body); string(body) != valueOrDefault(testCase.body, requestBody) {
+					t.Errorf("expected handler to get body %s, got %s", requestBody, body)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(testCase.handlerStatusCode)
+				_, _ = w.Write([]byte(`{"ok":true}`))
+			}))
+
+			r := httptest.NewRequest(valueOrDefault(testCase.method, http.MethodPost), "/api/v1/pay", strings.NewReader(valueOrDefault(testCase.body, requestBody)))
+			r.Header.Set(requestIDHeader, valueOrDefault(testCase.requestID, requestID))
+			r.Header.Set(testUserIDHeader, valueOrDefault(testCase.userID, userID))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != testCase.expectedStatus {
+				t.Errorf("expected status %d, got %d", testCase.expectedStatus, w.Code)
+			}
+			if handled != testCase.expectedHandled {
+				t.Errorf("expected request handled %v, got %v", testCase.expectedHandled, handled)
+			}
+			if replayed := w.Header().Get(replayedHeader) == "true"; replayed != testCase.expectedReplayed {
+				t.Errorf("expected response replayed %v, got %v", testCase.expectedReplayed, replayed)
+			}
+			if testCase.expectedErrorCode != 0 {
+				var info errorInfo
+				if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil || info.Code != testCase.expectedErrorCode {
+					t.Errorf("expected error code %d, got %s", testCase.expectedErrorCode, w.Body.String())
+				}
+			}
+			if testCase.expectedResponse != nil && testCase.expectedReplayed && w.Body.String() != string(testCase.expectedResponse.Body) {
+				t.Errorf("expected replayed body %s, got %s", testCase.expectedResponse.Body, w.Body.String())
+			}
+
+			record, ok := repo.records[key]
+			var response *idempotency.Response
+			if ok {
+				response = record.Response
+			}
+			if fmt.Sprint(response) != fmt.Sprint(testCase.expectedResponse) {
+				t.Errorf("expected stored response %v, got %v", testCase.expectedResponse, response)
+			}
+		})
+	}
+}
+
+func TestMiddlewareReplaysRetry(t *testing.T) {
+	repo := newFakeRepository()
+	calls := 0
+	handler := newTestMiddleware(repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"call":%d}`, calls)
+	}))
+	requestID := string(uuid.GenerateNew())
+
+	var responses []*httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/pay", strings.NewReader(`{"amount":"10.00"}`))
+		r.Header.Set(requestIDHeader, requestID)
+		r.Header.Set(testUserIDHeader, "user")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		responses = append(responses, w)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected request handled once, got %d", calls)
+	}
+	first, retry := responses[0], responses[1]
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() || retry.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("expected replayed %d %s, got %d %s", first.Code, first.Body.String(), retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(replayedHeader) != "true" {
+		t.Errorf("expected %s header on retry", replayedHeader)
+	}
+}
+
+func newTestMiddleware(repo idempotency.Repository) func(http.Handler) http.Handler {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	m := &middleware{
+		repo: repo,
+		userIDExtractor: func(r *http.Request) (string, bool) {
+			userID := r.Header.Get(testUserIDHeader)
+			return userID, userID != "-"
+		},
+		logger: logger,
+	}
+	return m.wrap
+}
+
+func valueOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// fakeRepository follows the takeover rules of the postgres repository
+type fakeRepository struct {
+	records map[idempotency.Key]idempotency.Record
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{records: map[idempotency.Key]idempotency.Record{}}
+}
+
+func (repo *fakeRepository) Create(key idempotency.Key, requestHash string, lockTimeout time.Duration) (*idempotency.Record, error) {
+	record, ok := repo.records[key]
+	if !ok {
+		repo.records[key] = idempotency.Record{Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+		return nil, nil
+	}
+	if record.Response == nil && record.RequestHash == requestHash && record.CreatedAt.Before(time.Now().Add(-lockTimeout)) {
+		record.CreatedAt = time.Now()
+		repo.records[key] = record
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (repo *fakeRepository) SaveResponse(key idempotency.Key, response idempotency.Response) error {
+	record := repo.records[key]
+	record.Response = &response
+	repo.records[key] = record
+	return nil
+}
+
+func (repo *fakeRepository) Remove(key idempotency.Key) error {
+	delete(repo.records, key)
+	return nil
+}
+
+func (repo *fakeRepository) RemoveCreatedBefore(time.Time) (int64, error) {
+	return 0, nil
+}
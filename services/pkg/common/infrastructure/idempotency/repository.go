@@ -0,0 +1,110 @@
+package idempotency
+
+import (
+	"arch-homework/pkg/common/app/idempotency"
+	"arch-homework/pkg/common/infrastructure/postgres"
+
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewRepository(client postgres.Client) idempotency.Repository {
+	return &repository{client: client}
+}
+
+type repository struct {
+	client postgres.Client
+}
+
+func (repo *repository) Create(key idempotency.Key, requestHash string, lockTimeout time.Duration) (*idempotency.Record, error) {
+	// conflicting record is locked by the insert, so only one retry takes over the lost request
+	const insertQuery = `
+			INSERT INTO idempotency_key (request_id, user_id, request_hash)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (request_id, user_id) DO UPDATE SET created_at = NOW()
+				WHERE idempotency_key.status_code IS NULL
+					AND idempotency_key.request_hash = excluded.request_hash
+					AND idempotency_key.created_at < NOW() - make_interval(secs => $4)
+			RETURNING request_id
+		`
+	const selectQuery = `
+			SELECT request_id, user_id, request_hash, status_code, content_type, body, created_at
+			FROM idempotency_key WHERE request_id = $1 AND user_id = $2
+		`
+
+	var requestID string
+	err := repo.client.Get(&requestID, insertQuery, string(key.RequestID), key.UserID, requestHash, lockTimeout.Seconds())
+	if err == nil {
+		return nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, errors.WithStack(err)
+	}
+
+	var record sqlxRecord
+	err = repo.client.Get(&record, selectQuery, string(key.RequestID), key.UserID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	res := sqlxRecordToRecord(&record)
+	return &res, nil
+}
+
+func (repo *repository) SaveResponse(key idempotency.Key, response idempotency.Response) error {
+	const query = `
+			UPDATE idempotency_key SET status_code = $3, content_type = $4, body = $5
+			WHERE request_id = $1 AND user_id = $2
+		`
+
+	_, err := repo.client.Exec(query, string(key.RequestID), key.UserID, response.StatusCode, response.ContentType, response.Body)
+	return errors.WithStack(err)
+}
+
+func (repo *repository) Remove(key idempotency.Key) error {
+	const query = `DELETE FROM idempotency_key WHERE request_id = $1 AND user_id = $2`
+
+	_, err := repo.client.Exec(query, string(key.RequestID), key.UserID)
+	return errors.WithStack(err)
+}
+
+func (repo *repository) RemoveCreatedBefore(time time.Time) (int64, error) {
+	const query = `DELETE FROM idempotency_key WHERE created_at < $1`
+
+	res, err := repo.client.Exec(query, time)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	removed, err := res.RowsAffected()
+	return removed, errors.WithStack(err)
+}
+
+func sqlxRecordToRecord(record *sqlxRecord) idempotency.Record {
+	res := idempotency.Record{
+		Key: idempotency.Key{
+			RequestID: idempotency.RequestID(record.RequestID),
+			UserID:    record.UserID,
+		},
+		RequestHash: record.RequestHash,
+		CreatedAt:   record.CreatedAt,
+	}
+	if record.StatusCode.Valid {
+		res.Response = &idempotency.Response{
+			StatusCode:  int(record.StatusCode.Int32),
+			ContentType: record.ContentType.String,
+			Body:        record.Body,
+		}
+	}
+	return res
+}
+
+type sqlxRecord struct {
+	RequestID   string         `db:"request_id"`
+	UserID      string         `db:"user_id"`
+	RequestHash string         `db:"request_hash"`
+	StatusCode  sql.NullInt32  `db:"status_code"`
+	ContentType sql.NullString `db:"content_type"`
+	Body        []byte         `db:"body"`
+	CreatedAt   time.Time      `db:"created_at"`
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
 	"github.com/rabbitmq/rabbitmq-stream-go-client/pkg/amqp"
 	"github.com/sirupsen/logrus"
 )
@@ -66,6 +67,10 @@ func (ec *eventConsumer) messageHandler(msg *amqp.Message) {
 func (ec *eventConsumer) handleEvent(eventData integrationevent.EventData) {
 	err := backoff.Retry(func() error {
 		err2 := ec.handler.Handle(eventData)
+		if errors.Cause(err2) == integrationevent.ErrEventSkipped {
+			ec.logger.Warnf("integration event '%s' with type '%s' skipped - %s", string(eventData.UID), eventData.Type, err2.Error())
+			return nil
+		}
 		if err2 != nil {
 			ec.logger.Errorf("error processing integration event - '%s'. attempt to retry", err2.Error())
 		}
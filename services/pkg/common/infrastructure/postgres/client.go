@@ -14,6 +14,8 @@ type Client interface {
 	Select(dest interface{}, query string, args ...interface{}) error
 	Get(dest interface{}, query string, args ...interface{}) error
 	Exec(query string, args ...interface{}) (sql.Result, error)
+	// Queryx returns rows to be read one by one, the caller closes them
+	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
 
 	NamedQuery(query string, arg interface{}) (*sqlx.Rows, error)
 	NamedExec(query string, arg interface{}) (sql.Result, error)
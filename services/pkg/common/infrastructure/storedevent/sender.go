@@ -8,6 +8,7 @@ import (
 
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -37,19 +38,26 @@ type sender struct {
 	repo           storedevent.EventStore
 	logger         *logrus.Logger
 	producer       streams.Producer
+	mutex          sync.Mutex
 	addedEventUids []integrationevent.EventUID
 }
 
 func (s *sender) EventStored(uid integrationevent.EventUID) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	s.addedEventUids = append(s.addedEventUids, uid)
 }
 
 func (s *sender) SendStoredEvents() {
-	if len(s.addedEventUids) == 0 {
+	s.mutex.Lock()
+	uids := s.addedEventUids
+	s.addedEventUids = nil
+	s.mutex.Unlock()
+
+	if len(uids) == 0 {
 		return
 	}
-	s.sendEvents(&s.addedEventUids)
-	s.addedEventUids = nil
+	s.sendEvents(&uids)
 }
 
 func (s *sender) msgConfirmation(id streams.MsgID, confirmed bool, err error) {
@@ -0,0 +1,152 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	keyTypeRSA      = "RSA"
+	keyTypeOKP      = "OKP"
+	curveEd25519    = "Ed25519"
+	keyUseSignature = "sig"
+)
+
+// JWKS is the JSON Web Key Set (RFC 7517) with public parts of signing keys
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWKS publishes public keys of the current signing key and the retired ones,
+// retired keys are kept until tokens signed with them expire
+func NewJWKS(keys ...SigningKey) (JWKS, error) {
+	jwks := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := newJWK(key.key.Public())
+		if err != nil {
+			return JWKS{}, err
+		}
+		jwk.Algorithm = key.method.Alg()
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks, nil
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// N and E are RSA modulus and exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Curve and X are Ed25519 curve name and public key
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+func newJWK(key crypto.PublicKey) (JWK, error) {
+	var jwk JWK
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		jwk = JWK{
+			KeyType: keyTypeRSA,
+			N:       encodeSegment(k.N.Bytes()),
+			E:       encodeSegment(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		jwk = JWK{
+			KeyType: keyTypeOKP,
+			Curve:   curveEd25519,
+			X:       encodeSegment(k),
+		}
+	default:
+		return JWK{}, errors.Wrapf(ErrUnsupportedKey, "%T", key)
+	}
+	jwk.Use = keyUseSignature
+	kid, err := jwk.thumbprint()
+	if err != nil {
+		return JWK{}, err
+	}
+	jwk.KeyID = kid
+	return jwk, nil
+}
+
+// thumbprint is RFC 7638 thumbprint, so the key id does not change when the same key is loaded again
+func (k JWK) thumbprint() (string, error) {
+	// required members in lexicographic order as the thumbprint demands
+	var members interface{}
+	switch k.KeyType {
+	case keyTypeRSA:
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.KeyType, k.N}
+	case keyTypeOKP:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Curve, k.KeyType, k.X}
+	default:
+		return "", errors.Wrapf(ErrUnsupportedKey, "key type %s", k.KeyType)
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	sum := sha256.Sum256(data)
+	return encodeSegment(sum[:]), nil
+}
+
+// publicKey returns the key with the signing method it verifies
+func (k JWK) publicKey() (crypto.PublicKey, jwt.SigningMethod, error) {
+	switch k.KeyType {
+	case keyTypeRSA:
+		n, err := decodeSegment(k.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		e, err := decodeSegment(k.E)
+		if err != nil {
+			return nil, nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > int64(^uint32(0)>>1) {
+			return nil, nil, errors.Wrapf(ErrUnsupportedKey, "key %s exponent", k.KeyID)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, jwt.SigningMethodRS256, nil
+	case keyTypeOKP:
+		if k.Curve != curveEd25519 {
+			return nil, nil, errors.Wrapf(ErrUnsupportedKey, "key %s curve %s", k.KeyID, k.Curve)
+		}
+		x, err := decodeSegment(k.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.Wrapf(ErrUnsupportedKey, "key %s size", k.KeyID)
+		}
+		return ed25519.PublicKey(x), jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, nil, errors.Wrapf(ErrUnsupportedKey, "key %s type %s", k.KeyID, k.KeyType)
+	}
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	return data, errors.WithStack(err)
+}
@@ -0,0 +1,112 @@
+package jwtauth
+
+import (
+	"crypto"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// keySetTTL limits how long keys removed from JWKS are still accepted
+	keySetTTL = 5 * time.Minute
+	// keySetMinRefreshInterval protects the auth service from refetches caused by tokens with unknown kid
+	keySetMinRefreshInterval = 10 * time.Second
+	keySetRequestTimeout     = 5 * time.Second
+	maxJWKSSize              = 1 << 20
+)
+
+var ErrUnknownKey = errors.New("unknown token signing key")
+
+type verificationKey struct {
+	publicKey crypto.PublicKey
+	method    jwt.SigningMethod
+}
+
+func newKeySet(url string) *keySet {
+	return &keySet{
+		url:    url,
+		client: &http.Client{Timeout: keySetRequestTimeout},
+		keys:   map[string]verificationKey{},
+	}
+}
+
+// keySet caches JWKS keys by kid, keys are fetched on the first token and refetched
+// when the cache is outdated or the token is signed with the key not seen yet after rotation
+type keySet struct {
+	url    string
+	client *http.Client
+
+	mutex       sync.Mutex
+	keys        map[string]verificationKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+func (s *keySet) find(kid string) (verificationKey, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key, found := s.keys[kid]
+	if found && time.Since(s.fetchedAt) < keySetTTL {
+		return key, nil
+	}
+	if time.Since(s.attemptedAt) >= keySetMinRefreshInterval {
+		s.attemptedAt = time.Now()
+		err := s.refresh()
+		// outdated keys are still better than rejecting all tokens while the auth service is unavailable
+		if err != nil && !found {
+			return verificationKey{}, err
+		}
+		key, found = s.keys[kid]
+	}
+	if !found {
+		return verificationKey{}, errors.Wrapf(ErrUnknownKey, "kid %q", kid)
+	}
+	return key, nil
+}
+
+func (s *keySet) refresh() error {
+	jwks, err := s.fetch()
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]verificationKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		publicKey, method, err := jwk.publicKey()
+		if err != nil {
+			// keys of unsupported types are skipped, the rest of the set is still usable
+			continue
+		}
+		keys[jwk.KeyID] = verificationKey{publicKey: publicKey, method: method}
+	}
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func (s *keySet) fetch() (JWKS, error) {
+	var jwks JWKS
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return jwks, errors.Wrap(err, "failed to fetch jwks")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return jwks, errors.Errorf("failed to fetch jwks: status %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return jwks, errors.Wrap(err, "failed to fetch jwks")
+	}
+	if err = json.Unmarshal(data, &jwks); err != nil {
+		return jwks, errors.Wrap(err, "failed to parse jwks")
+	}
+	return jwks, nil
+}
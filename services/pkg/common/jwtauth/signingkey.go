@@ -0,0 +1,89 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+var ErrUnsupportedKey = errors.New("unsupported signing key")
+
+// SigningKey is the private key tokens are signed with, ID is published as token kid header
+type SigningKey struct {
+	ID     string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+// NewSigningKey accepts RSA keys signing with RS256 and Ed25519 keys signing with EdDSA
+func NewSigningKey(key crypto.Signer) (SigningKey, error) {
+	var method jwt.SigningMethod
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return SigningKey{}, errors.Wrapf(ErrUnsupportedKey, "%T", key)
+	}
+	jwk, err := newJWK(key.Public())
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return SigningKey{ID: jwk.KeyID, method: method, key: key}, nil
+}
+
+// GenerateSigningKey creates the random Ed25519 key, tokens signed with it can not be verified after restart
+func GenerateSigningKey() (SigningKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return SigningKey{}, errors.WithStack(err)
+	}
+	return NewSigningKey(key)
+}
+
+// ParseSigningKeys reads all PKCS1 and PKCS8 private keys from PEM data in their order
+func ParseSigningKeys(data []byte) ([]SigningKey, error) {
+	var keys []SigningKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		key, err := parsePrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signingKey, err := NewSigningKey(key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, signingKey)
+	}
+	if len(keys) == 0 {
+		return nil, errors.Wrap(ErrUnsupportedKey, "no PEM encoded private keys found")
+	}
+	return keys, nil
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrap(ErrUnsupportedKey, err.Error())
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.Wrapf(ErrUnsupportedKey, "%T", key)
+	}
+	return signer, nil
+}
@@ -13,12 +13,14 @@ type TokenGenerator interface {
 	GenerateToken(userID, userLogin string) (string, error)
 }
 
-func NewTokenGenerator(key string) TokenGenerator {
-	return &tokenGenerator{key: []byte(key)}
+// NewTokenGenerator signs tokens with the key and puts its id to kid header,
+// so parsers pick the key from the published JWKS
+func NewTokenGenerator(key SigningKey) TokenGenerator {
+	return &tokenGenerator{key: key}
 }
 
 type tokenGenerator struct {
-	key []byte
+	key SigningKey
 }
 
 func (t *tokenGenerator) GenerateToken(userID, userLogin string) (string, error) {
@@ -32,8 +34,9 @@ func (t *tokenGenerator) GenerateToken(userID, userLogin string) (string, error)
 		ID:    userID,
 		Login: userLogin,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString(t.key)
+	token := jwt.NewWithClaims(t.key.method, claims)
+	token.Header[headerKeyID] = t.key.ID
+	tokenStr, err := token.SignedString(t.key.key)
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -5,18 +5,21 @@ import (
 	"github.com/pkg/errors"
 )
 
+const headerKeyID = "kid"
+
 var ErrInvalidToken = errors.New("invalid token")
 
 type TokenParser interface {
 	ParseToken(token string) (TokenData, error)
 }
 
-func NewTokenParser(key string) TokenParser {
-	return &tokenParser{key: []byte(key)}
+// NewTokenParser verifies tokens with public keys published by the auth service at jwksURL
+func NewTokenParser(jwksURL string) TokenParser {
+	return &tokenParser{keys: newKeySet(jwksURL)}
 }
 
 type tokenParser struct {
-	key []byte
+	keys *keySet
 }
 
 func (t *tokenParser) ParseToken(token string) (TokenData, error) {
@@ -24,14 +27,23 @@ func (t *tokenParser) ParseToken(token string) (TokenData, error) {
 
 	jwtToken, err := jwt.ParseWithClaims(
 		token, &claims, func(token *jwt.Token) (i interface{}, err error) {
-			return t.key, nil
+			kid, _ := token.Header[headerKeyID].(string)
+			key, err := t.keys.find(kid)
+			if err != nil {
+				return nil, err
+			}
+			// the key decides the algorithm, so the token can not pick a weaker one
+			if token.Method.Alg() != key.method.Alg() {
+				return nil, errors.Wrapf(ErrInvalidToken, "algorithm %s for key %s", token.Method.Alg(), kid)
+			}
+			return key.publicKey, nil
 		},
 	)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	if !jwtToken.Valid || jwtToken.Method.Alg() != "HS256" {
+	if !jwtToken.Valid {
 		return nil, errors.WithStack(ErrInvalidToken)
 	}
 
@@ -2,9 +2,24 @@ package app
 
 import (
 	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/json"
 )
 
+const typeNotificationSent = "notification.notification_sent"
+
+var notificationTypeEventNames = map[NotificationType]string{
+	TypeOrderConfirmed:   "order_confirmed",
+	TypeOrderRejected:    "order_rejected",
+	TypeOrderCancelled:   "order_cancelled",
+	TypePaymentRefunded:  "payment_refunded",
+	TypeAccountOverdrawn: "account_overdrawn",
+	TypeTransferSent:     "transfer_sent",
+	TypeTransferReceived: "transfer_received",
+}
+
 type ProcessedEventRepository interface {
 	SetEventProcessed(uid integrationevent.EventUID) (alreadyProcessed bool, err error)
 }
@@ -21,6 +36,22 @@ func NewOrderRejectedEvent(userID UserID, orderID uuid.UUID) UserEvent {
 	return orderRejectedEvent{userID: userID, orderID: orderID}
 }
 
+func NewOrderCancelledEvent(userID UserID, orderID uuid.UUID) UserEvent {
+	return orderCancelledEvent{userID: userID, orderID: orderID}
+}
+
+func NewPaymentRefundedEvent(userID UserID, orderID uuid.UUID, amount money.Money) UserEvent {
+	return paymentRefundedEvent{userID: userID, orderID: orderID, amount: amount}
+}
+
+func NewAccountOverdrawnEvent(userID UserID, balance, creditLimit money.Money) UserEvent {
+	return accountOverdrawnEvent{userID: userID, balance: balance, creditLimit: creditLimit}
+}
+
+func NewTransferCompletedEvent(senderID, recipientID UserID, amount money.Money) UserEvent {
+	return transferCompletedEvent{senderID: senderID, recipientID: recipientID, amount: amount}
+}
+
 type orderConfirmedEvent struct {
 	userID  UserID
 	orderID uuid.UUID
@@ -38,3 +69,64 @@ type orderRejectedEvent struct {
 func (e orderRejectedEvent) UserID() UserID {
 	return e.userID
 }
+
+type orderCancelledEvent struct {
+	userID  UserID
+	orderID uuid.UUID
+}
+
+func (e orderCancelledEvent) UserID() UserID {
+	return e.userID
+}
+
+type paymentRefundedEvent struct {
+	userID  UserID
+	orderID uuid.UUID
+	amount  money.Money
+}
+
+func (e paymentRefundedEvent) UserID() UserID {
+	return e.userID
+}
+
+type accountOverdrawnEvent struct {
+	userID      UserID
+	balance     money.Money
+	creditLimit money.Money
+}
+
+func (e accountOverdrawnEvent) UserID() UserID {
+	return e.userID
+}
+
+// transferCompletedEvent concerns both users, UserID returns the sender
+type transferCompletedEvent struct {
+	senderID    UserID
+	recipientID UserID
+	amount      money.Money
+}
+
+func (e transferCompletedEvent) UserID() UserID {
+	return e.senderID
+}
+
+// NewNotificationSentEvent orderID is empty for notifications not related to orders
+func NewNotificationSentEvent(notificationType NotificationType, userID UserID, orderID uuid.UUID) integrationevent.EventData {
+	body, _ := json.Marshal(notificationSentEventBody{
+		OrderID:          string(orderID),
+		UserID:           string(userID),
+		NotificationType: notificationTypeEventNames[notificationType],
+	})
+
+	return integrationevent.EventData{
+		UID:  integrationevent.EventUID(uuid.GenerateNew()),
+		Type: typeNotificationSent,
+		Body: string(body),
+	}
+}
+
+type notificationSentEventBody struct {
+	OrderID          string `json:"order_id,omitempty"`
+	UserID           string `json:"user_id"`
+	NotificationType string `json:"notification_type"`
+}
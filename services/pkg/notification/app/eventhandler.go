@@ -2,21 +2,24 @@ package app
 
 import (
 	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/storedevent"
 )
 
 type IntegrationEventParser interface {
 	ParseIntegrationEvent(event integrationevent.EventData) (UserEvent, error)
 }
 
-func NewEventHandler(trUnitFactory TransactionalUnitFactory, parser IntegrationEventParser) integrationevent.EventHandler {
+func NewEventHandler(trUnitFactory TransactionalUnitFactory, eventSender storedevent.Sender, parser IntegrationEventParser) integrationevent.EventHandler {
 	return &eventHandler{
 		trUnitFactory: trUnitFactory,
+		eventSender:   eventSender,
 		parser:        parser,
 	}
 }
 
 type eventHandler struct {
 	trUnitFactory TransactionalUnitFactory
+	eventSender   storedevent.Sender
 	parser        IntegrationEventParser
 }
 
@@ -26,7 +29,7 @@ func (handler *eventHandler) Handle(event integrationevent.EventData) error {
 		return err
 	}
 
-	return handler.executeInTransaction(func(provider RepositoryProvider) error {
+	err = handler.executeInTransaction(func(provider RepositoryProvider) error {
 		eventRepo := provider.ProcessedEventRepo()
 		alreadyProcessed, err := eventRepo.SetEventProcessed(event.UID)
 		if err != nil {
@@ -36,15 +39,30 @@ func (handler *eventHandler) Handle(event integrationevent.EventData) error {
 			return nil
 		}
 
+		service := NewNotificationService(provider, handler.eventSender)
 		switch e := parsedEvent.(type) {
 		case orderConfirmedEvent:
-			return handleOrderConfirmedEvent(provider, e)
+			return service.AddNotification(TypeOrderConfirmed, e.UserID(), e.orderID)
 		case orderRejectedEvent:
-			return handleOrderRejectedEvent(provider, e)
+			return service.AddNotification(TypeOrderRejected, e.UserID(), e.orderID)
+		case orderCancelledEvent:
+			return service.AddNotification(TypeOrderCancelled, e.UserID(), e.orderID)
+		case paymentRefundedEvent:
+			return service.AddRefundNotification(e.UserID(), e.orderID, e.amount)
+		case accountOverdrawnEvent:
+			return service.AddOverdraftNotification(e.UserID(), e.balance, e.creditLimit)
+		case transferCompletedEvent:
+			return service.AddTransferNotifications(e.senderID, e.recipientID, e.amount)
 		default:
 			return nil
 		}
 	})
+	if err != nil {
+		return err
+	}
+
+	handler.eventSender.SendStoredEvents()
+	return nil
 }
 
 func (handler *eventHandler) executeInTransaction(f func(RepositoryProvider) error) (err error) {
@@ -59,13 +77,3 @@ func (handler *eventHandler) executeInTransaction(f func(RepositoryProvider) err
 	err = f(trUnit)
 	return err
 }
-
-func handleOrderConfirmedEvent(provider RepositoryProvider, e orderConfirmedEvent) error {
-	service := NewNotificationService(provider.NotificationRepository())
-	return service.AddNotification(TypeOrderConfirmed, e.UserID(), e.orderID)
-}
-
-func handleOrderRejectedEvent(provider RepositoryProvider, e orderRejectedEvent) error {
-	service := NewNotificationService(provider.NotificationRepository())
-	return service.AddNotification(TypeOrderRejected, e.UserID(), e.orderID)
-}
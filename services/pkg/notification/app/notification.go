@@ -10,8 +10,13 @@ type UserID uuid.UUID
 type NotificationType int
 
 const (
-	TypeOrderConfirmed NotificationType = 1
-	TypeOrderRejected  NotificationType = 2
+	TypeOrderConfirmed   NotificationType = 1
+	TypeOrderRejected    NotificationType = 2
+	TypeOrderCancelled   NotificationType = 3
+	TypePaymentRefunded  NotificationType = 4
+	TypeAccountOverdrawn NotificationType = 5
+	TypeTransferSent     NotificationType = 6
+	TypeTransferReceived NotificationType = 7
 )
 
 type Notification struct {
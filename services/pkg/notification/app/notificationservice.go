@@ -1,6 +1,8 @@
 package app
 
 import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/storedevent"
 	"arch-homework/pkg/common/app/uuid"
 
 	"fmt"
@@ -8,18 +10,23 @@ import (
 	"github.com/pkg/errors"
 )
 
-func NewNotificationService(repo NotificationRepository) NotificationService {
+func NewNotificationService(provider RepositoryProvider, eventSender storedevent.Sender) NotificationService {
 	return &notificationService{
-		repo: repo,
+		provider:    provider,
+		eventSender: eventSender,
 	}
 }
 
 type NotificationService interface {
 	AddNotification(notificationType NotificationType, userID UserID, orderID uuid.UUID) error
+	AddRefundNotification(userID UserID, orderID uuid.UUID, amount money.Money) error
+	AddOverdraftNotification(userID UserID, balance, creditLimit money.Money) error
+	AddTransferNotifications(senderID, recipientID UserID, amount money.Money) error
 }
 
 type notificationService struct {
-	repo NotificationRepository
+	provider    RepositoryProvider
+	eventSender storedevent.Sender
 }
 
 func (n *notificationService) AddNotification(notificationType NotificationType, userID UserID, orderID uuid.UUID) error {
@@ -27,12 +34,45 @@ func (n *notificationService) AddNotification(notificationType NotificationType,
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	return n.addNotification(notificationType, userID, orderID, msg)
+}
+
+func (n *notificationService) AddRefundNotification(userID UserID, orderID uuid.UUID, amount money.Money) error {
+	msg := fmt.Sprintf("Payment for order %s refunded, %s returned to the account", string(orderID), amount.String())
+	return n.addNotification(TypePaymentRefunded, userID, orderID, msg)
+}
+
+func (n *notificationService) AddOverdraftNotification(userID UserID, balance, creditLimit money.Money) error {
+	msg := fmt.Sprintf("Account balance is negative %s, credit limit %s", balance.String(), creditLimit.String())
+	return n.addNotification(TypeAccountOverdrawn, userID, "", msg)
+}
+
+// AddTransferNotifications informs both the sender and the recipient
+func (n *notificationService) AddTransferNotifications(senderID, recipientID UserID, amount money.Money) error {
+	msg := fmt.Sprintf("%s sent to user %s", amount.String(), string(recipientID))
+	if err := n.addNotification(TypeTransferSent, senderID, "", msg); err != nil {
+		return err
+	}
+	msg = fmt.Sprintf("%s received from user %s", amount.String(), string(senderID))
+	return n.addNotification(TypeTransferReceived, recipientID, "", msg)
+}
+
+func (n *notificationService) addNotification(notificationType NotificationType, userID UserID, orderID uuid.UUID, msg string) error {
 	notification := Notification{
 		Type:    notificationType,
 		UserID:  userID,
 		Message: msg,
 	}
-	return n.repo.Store(&notification)
+	if err := n.provider.NotificationRepository().Store(&notification); err != nil {
+		return err
+	}
+
+	event := NewNotificationSentEvent(notificationType, userID, orderID)
+	if err := n.provider.EventStore().Add(event); err != nil {
+		return err
+	}
+	n.eventSender.EventStored(event.UID)
+	return nil
 }
 
 func messageForOrder(notificationType NotificationType, orderID uuid.UUID) (string, error) {
@@ -41,6 +81,8 @@ func messageForOrder(notificationType NotificationType, orderID uuid.UUID) (stri
 		return fmt.Sprintf("Order %s confirmed", string(orderID)), nil
 	case TypeOrderRejected:
 		return fmt.Sprintf("Order %s rejected", string(orderID)), nil
+	case TypeOrderCancelled:
+		return fmt.Sprintf("Order %s cancelled, payment refunded", string(orderID)), nil
 	default:
 		return "", errors.New("unknown notification type")
 	}
@@ -1,8 +1,11 @@
 package app
 
+import "arch-homework/pkg/common/app/storedevent"
+
 type RepositoryProvider interface {
 	NotificationRepository() NotificationRepository
 	ProcessedEventRepo() ProcessedEventRepository
+	EventStore() storedevent.EventStore
 }
 
 type TransactionalUnit interface {
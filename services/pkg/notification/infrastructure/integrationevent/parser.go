@@ -2,6 +2,7 @@ package integrationevent
 
 import (
 	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/uuid"
 	"arch-homework/pkg/notification/app"
 
@@ -12,6 +13,10 @@ import (
 
 const typeOrderConfirmed = "order.order_confirmed"
 const typeOrderRejected = "order.order_rejected"
+const typeOrderCancelled = "order.order_cancelled"
+const typeRefundCompleted = "billing.refund_completed"
+const typeAccountOverdrawn = "billing.account_overdrawn"
+const typeTransferCompleted = "billing.transfer_completed"
 
 func NewEventParser() app.IntegrationEventParser {
 	return eventParser{}
@@ -26,6 +31,14 @@ func (e eventParser) ParseIntegrationEvent(event integrationevent.EventData) (ap
 		return parseOrderConfirmedEvent(event.Body)
 	case typeOrderRejected:
 		return parseOrderRejectedEvent(event.Body)
+	case typeOrderCancelled:
+		return parseOrderCancelledEvent(event.Body)
+	case typeRefundCompleted:
+		return parseRefundCompletedEvent(event.Body)
+	case typeAccountOverdrawn:
+		return parseAccountOverdrawnEvent(event.Body)
+	case typeTransferCompleted:
+		return parseTransferCompletedEvent(event.Body)
 	default:
 		return nil, nil
 	}
@@ -47,6 +60,56 @@ func parseOrderRejectedEvent(strBody string) (app.UserEvent, error) {
 	return app.NewOrderRejectedEvent(app.UserID(body.UserID), uuid.UUID(body.OrderID)), nil
 }
 
+func parseOrderCancelledEvent(strBody string) (app.UserEvent, error) {
+	body, err := parseOrderEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewOrderCancelledEvent(app.UserID(body.UserID), uuid.UUID(body.OrderID)), nil
+}
+
+func parseRefundCompletedEvent(strBody string) (app.UserEvent, error) {
+	var body refundCompletedEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = uuid.ValidateUUID(body.UserID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = uuid.ValidateUUID(body.OrderID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return app.NewPaymentRefundedEvent(app.UserID(body.UserID), uuid.UUID(body.OrderID), body.Amount), nil
+}
+
+func parseAccountOverdrawnEvent(strBody string) (app.UserEvent, error) {
+	var body accountOverdrawnEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = uuid.ValidateUUID(body.UserID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return app.NewAccountOverdrawnEvent(app.UserID(body.UserID), body.Balance, body.CreditLimit), nil
+}
+
+func parseTransferCompletedEvent(strBody string) (app.UserEvent, error) {
+	var body transferCompletedEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = uuid.ValidateUUID(body.SenderID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = uuid.ValidateUUID(body.RecipientID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return app.NewTransferCompletedEvent(app.UserID(body.SenderID), app.UserID(body.RecipientID), body.Amount), nil
+}
+
 func parseOrderEvent(strBody string) (orderEventBody, error) {
 	var body orderEventBody
 	err := json.Unmarshal([]byte(strBody), &body)
@@ -65,3 +128,21 @@ type orderEventBody struct {
 	OrderID string `json:"order_id"`
 	UserID  string `json:"user_id"`
 }
+
+type refundCompletedEventBody struct {
+	OrderID string      `json:"order_id"`
+	UserID  string      `json:"user_id"`
+	Amount  money.Money `json:"amount"`
+}
+
+type accountOverdrawnEventBody struct {
+	UserID      string      `json:"user_id"`
+	Balance     money.Money `json:"balance"`
+	CreditLimit money.Money `json:"credit_limit"`
+}
+
+type transferCompletedEventBody struct {
+	SenderID    string      `json:"sender_id"`
+	RecipientID string      `json:"recipient_id"`
+	Amount      money.Money `json:"amount"`
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"arch-homework/pkg/common/app/storedevent"
 	"arch-homework/pkg/common/infrastructure/postgres"
 	"arch-homework/pkg/notification/app"
 
@@ -35,6 +36,10 @@ func (t *transactionalUnit) ProcessedEventRepo() app.ProcessedEventRepository {
 	return NewProcessedEventRepository(t.transaction)
 }
 
+func (t *transactionalUnit) EventStore() storedevent.EventStore {
+	return NewEventStore(t.transaction)
+}
+
 func (t *transactionalUnit) Complete(err error) error {
 	if err != nil {
 		rollbackErr := t.transaction.Rollback()
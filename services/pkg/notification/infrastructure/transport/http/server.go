@@ -27,8 +27,13 @@ const (
 )
 
 const (
-	notificationTypeOrderConfirmed = "OrderConfirmed"
-	notificationTypeOrderRejected  = "OrderRejected"
+	notificationTypeOrderConfirmed   = "OrderConfirmed"
+	notificationTypeOrderRejected    = "OrderRejected"
+	notificationTypeOrderCancelled   = "OrderCancelled"
+	notificationTypePaymentRefunded  = "PaymentRefunded"
+	notificationTypeAccountOverdrawn = "AccountOverdrawn"
+	notificationTypeTransferSent     = "TransferSent"
+	notificationTypeTransferReceived = "TransferReceived"
 )
 
 const authTokenHeader = "X-Auth-Token"
@@ -174,6 +179,16 @@ func notificationTypeToString(t app.NotificationType) (string, error) {
 		return notificationTypeOrderConfirmed, nil
 	case app.TypeOrderRejected:
 		return notificationTypeOrderRejected, nil
+	case app.TypeOrderCancelled:
+		return notificationTypeOrderCancelled, nil
+	case app.TypePaymentRefunded:
+		return notificationTypePaymentRefunded, nil
+	case app.TypeAccountOverdrawn:
+		return notificationTypeAccountOverdrawn, nil
+	case app.TypeTransferSent:
+		return notificationTypeTransferSent, nil
+	case app.TypeTransferReceived:
+		return notificationTypeTransferReceived, nil
 	default:
 		return "", errors.New("unknown notification type")
 	}
@@ -1,5 +0,0 @@
-package app
-
-type BillingClient interface {
-	ProcessOrderPayment(userID UserID, price Price) (succeeded bool, err error)
-}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewCatalogService(dbDependency DBDependency) *CatalogService {
+	return &CatalogService{
+		readRepo:      dbDependency.ProductRepositoryRead(),
+		trUnitFactory: dbDependency,
+	}
+}
+
+type CatalogService struct {
+	readRepo      ProductRepositoryRead
+	trUnitFactory TransactionalUnitFactory
+}
+
+func (s *CatalogService) Create(sku SKU, name string, price money.Money, active bool) error {
+	product := Product{
+		SKU:          sku,
+		Name:         name,
+		Price:        price,
+		Active:       active,
+		CreationDate: time.Now(),
+		UpdateDate:   time.Now(),
+	}
+	if err := product.validate(); err != nil {
+		return err
+	}
+
+	return s.executeInTransaction(func(provider RepositoryProvider) error {
+		repo := provider.ProductRepository()
+		_, err := repo.FindBySKU(sku)
+		if err == nil {
+			return errors.WithStack(ErrProductAlreadyExists)
+		}
+		if errors.Cause(err) != ErrProductNotFound {
+			return err
+		}
+		return repo.Store(&product)
+	})
+}
+
+// Update changes catalog product, already created orders keep their price
+func (s *CatalogService) Update(sku SKU, name string, price money.Money, active bool) error {
+	return s.executeInTransaction(func(provider RepositoryProvider) error {
+		repo := provider.ProductRepository()
+		product, err := repo.FindBySKU(sku)
+		if err != nil {
+			return err
+		}
+		product.Name = name
+		product.Price = price
+		product.Active = active
+		product.UpdateDate = time.Now()
+		if err = product.validate(); err != nil {
+			return err
+		}
+		return repo.Store(product)
+	})
+}
+
+func (s *CatalogService) Remove(sku SKU) error {
+	return s.executeInTransaction(func(provider RepositoryProvider) error {
+		repo := provider.ProductRepository()
+		if _, err := repo.FindBySKU(sku); err != nil {
+			return err
+		}
+		return repo.Remove(sku)
+	})
+}
+
+func (s *CatalogService) Get(sku SKU) (*Product, error) {
+	return s.readRepo.FindBySKU(sku)
+}
+
+func (s *CatalogService) List(activeOnly bool) ([]Product, error) {
+	return s.readRepo.FindAll(activeOnly)
+}
+
+func (s *CatalogService) executeInTransaction(f func(RepositoryProvider) error) (err error) {
+	var trUnit TransactionalUnit
+	trUnit, err = s.trUnitFactory.NewTransactionalUnit()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = trUnit.Complete(err)
+	}()
+	err = f(trUnit)
+	return err
+}
@@ -4,12 +4,18 @@ import "arch-homework/pkg/common/app/storedevent"
 
 type RepositoryProvider interface {
 	OrderRepository() OrderRepository
-	ProcessedRequestRepository() ProcessedRequestRepository
+	OrderHistoryRepository() OrderHistoryRepository
+	PromoCodeRepository() PromoCodeRepository
+	ProductRepository() ProductRepository
+	ProcessedEventRepository() ProcessedEventRepository
 	EventStore() storedevent.EventStore
 }
 
 type ReadRepositoryProvider interface {
 	OrderRepositoryRead() OrderRepositoryRead
+	OrderHistoryRepositoryRead() OrderHistoryRepositoryRead
+	PromoCodeRepositoryRead() PromoCodeRepositoryRead
+	ProductRepositoryRead() ProductRepositoryRead
 }
 
 type TransactionalUnit interface {
@@ -2,27 +2,217 @@ package app
 
 import (
 	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/uuid"
 	"encoding/json"
+
+	"github.com/pkg/errors"
 )
 
+const typeOrderCreated = "order.order_created"
 const typeOrderConfirmed = "order.order_confirmed"
 const typeOrderRejected = "order.order_rejected"
+const typeOrderCancelled = "order.order_cancelled"
+const typeOrderCompleted = "order.order_completed"
 
-func NewOrderConfirmedEvent(orderID OrderID, userID UserID) integrationevent.EventData {
-	body, _ := json.Marshal(orderEventBody{
-		OrderID: string(orderID),
-		UserID:  string(userID),
+type ProcessedEventRepository interface {
+	SetEventProcessed(uid integrationevent.EventUID) (alreadyProcessed bool, err error)
+}
+
+// OrderEvent is reported by other services for the order, e.g. payment or stock reservation result
+type OrderEvent interface {
+	OrderID() OrderID
+}
+
+func NewPaymentAuthorizedEvent(orderID OrderID, paymentID PaymentID) OrderEvent {
+	return paymentAuthorizedEvent{orderID: orderID, paymentID: paymentID}
+}
+
+func NewPaymentFailedEvent(orderID OrderID, paymentID PaymentID, reason string) OrderEvent {
+	return paymentFailedEvent{orderID: orderID, paymentID: paymentID, reason: reason}
+}
+
+func NewPaymentCapturedEvent(orderID OrderID) OrderEvent {
+	return paymentCapturedEvent{orderID: orderID}
+}
+
+// NewPaymentVoidedEvent reason is set when the payment authorization has expired
+func NewPaymentVoidedEvent(orderID OrderID, reason string) OrderEvent {
+	return paymentVoidedEvent{orderID: orderID, reason: reason}
+}
+
+// NewPaymentRefundedEvent amount is the refunded part of the order payment
+func NewPaymentRefundedEvent(orderID OrderID, amount money.Money, fullyRefunded bool) OrderEvent {
+	return paymentRefundedEvent{orderID: orderID, amount: amount, fullyRefunded: fullyRefunded}
+}
+
+func NewStockReservedEvent(orderID OrderID) OrderEvent {
+	return stockReservedEvent{orderID: orderID}
+}
+
+func NewStockReservationFailedEvent(orderID OrderID, reason string, sku SKU) OrderEvent {
+	return stockReservationFailedEvent{orderID: orderID, reason: reason, sku: sku}
+}
+
+func NewNotificationSentEvent(orderID OrderID, notificationType string) OrderEvent {
+	return notificationSentEvent{orderID: orderID, notificationType: notificationType}
+}
+
+type paymentAuthorizedEvent struct {
+	orderID   OrderID
+	paymentID PaymentID
+}
+
+func (e paymentAuthorizedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+type paymentFailedEvent struct {
+	orderID   OrderID
+	paymentID PaymentID
+	reason    string
+}
+
+func (e paymentFailedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+type paymentCapturedEvent struct {
+	orderID OrderID
+}
+
+func (e paymentCapturedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+type paymentVoidedEvent struct {
+	orderID OrderID
+	reason  string
+}
+
+func (e paymentVoidedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+func (e paymentVoidedEvent) expired() bool {
+	return e.reason != ""
+}
+
+type paymentRefundedEvent struct {
+	orderID       OrderID
+	amount        money.Money
+	fullyRefunded bool
+}
+
+func (e paymentRefundedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+type stockReservedEvent struct {
+	orderID OrderID
+}
+
+func (e stockReservedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+type stockReservationFailedEvent struct {
+	orderID OrderID
+	reason  string
+	sku     SKU
+}
+
+func (e stockReservationFailedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+type notificationSentEvent struct {
+	orderID          OrderID
+	notificationType string
+}
+
+func (e notificationSentEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+func NewOrderCreatedEvent(order *Order) integrationevent.EventData {
+	return newOrderWithItemsEvent(typeOrderCreated, order)
+}
+
+func NewOrderConfirmedEvent(order *Order) integrationevent.EventData {
+	return newOrderWithItemsEvent(typeOrderConfirmed, order)
+}
+
+// NewOrderRejectedEvent asks billing to release the order payment when it was authorized before rejection
+func NewOrderRejectedEvent(order *Order) integrationevent.EventData {
+	body, _ := json.Marshal(orderRejectedEventBody{
+		orderWithItemsEventBody: newOrderWithItemsEventBody(order),
+		RefundPayment:           order.PaymentRefundRequired(),
 	})
 
 	return integrationevent.EventData{
 		UID:  newUID(),
-		Type: typeOrderConfirmed,
+		Type: typeOrderRejected,
+		Body: string(body),
+	}
+}
+
+func NewOrderCancelledEvent(order *Order) integrationevent.EventData {
+	return newOrderWithItemsEvent(typeOrderCancelled, order)
+}
+
+func NewOrderCompletedEvent(orderID OrderID, userID UserID) integrationevent.EventData {
+	return newOrderEvent(typeOrderCompleted, orderID, userID)
+}
+
+func newOrderStatusChangedEvent(order *Order) (integrationevent.EventData, error) {
+	switch order.Status {
+	case OrderStatusPending:
+		return NewOrderCreatedEvent(order), nil
+	case OrderStatusPaid:
+		return NewOrderConfirmedEvent(order), nil
+	case OrderStatusRejected:
+		return NewOrderRejectedEvent(order), nil
+	case OrderStatusCancelled:
+		return NewOrderCancelledEvent(order), nil
+	case OrderStatusCompleted:
+		return NewOrderCompletedEvent(order.ID, order.UserID), nil
+	default:
+		return integrationevent.EventData{}, errors.New("unknown order status")
+	}
+}
+
+func newOrderWithItemsEvent(eventType string, order *Order) integrationevent.EventData {
+	body, _ := json.Marshal(newOrderWithItemsEventBody(order))
+
+	return integrationevent.EventData{
+		UID:  newUID(),
+		Type: eventType,
 		Body: string(body),
 	}
 }
 
-func NewOrderRejectedEvent(orderID OrderID, userID UserID) integrationevent.EventData {
+func newOrderWithItemsEventBody(order *Order) orderWithItemsEventBody {
+	items := make([]orderItemEventBody, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, orderItemEventBody{
+			SKU:       string(item.SKU),
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	return orderWithItemsEventBody{
+		OrderID:   string(order.ID),
+		UserID:    string(order.UserID),
+		Price:     order.Price,
+		Discount:  order.Discount,
+		PromoCode: string(order.PromoCode),
+		Items:     items,
+	}
+}
+
+func newOrderEvent(eventType string, orderID OrderID, userID UserID) integrationevent.EventData {
 	body, _ := json.Marshal(orderEventBody{
 		OrderID: string(orderID),
 		UserID:  string(userID),
@@ -30,7 +220,7 @@ func NewOrderRejectedEvent(orderID OrderID, userID UserID) integrationevent.Even
 
 	return integrationevent.EventData{
 		UID:  newUID(),
-		Type: typeOrderRejected,
+		Type: eventType,
 		Body: string(body),
 	}
 }
@@ -43,3 +233,24 @@ type orderEventBody struct {
 	OrderID string `json:"order_id"`
 	UserID  string `json:"user_id"`
 }
+
+type orderWithItemsEventBody struct {
+	OrderID   string               `json:"order_id"`
+	UserID    string               `json:"user_id"`
+	Price     money.Money          `json:"price"`
+	Discount  money.Money          `json:"discount"`
+	PromoCode string               `json:"promo_code,omitempty"`
+	Items     []orderItemEventBody `json:"items"`
+}
+
+type orderRejectedEventBody struct {
+	orderWithItemsEventBody
+	RefundPayment bool `json:"refund_payment"`
+}
+
+type orderItemEventBody struct {
+	SKU       string      `json:"sku"`
+	Name      string      `json:"name"`
+	Quantity  uint64      `json:"quantity"`
+	UnitPrice money.Money `json:"unit_price"`
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/storedevent"
+
+	"github.com/pkg/errors"
+)
+
+type IntegrationEventParser interface {
+	ParseIntegrationEvent(event integrationevent.EventData) (OrderEvent, error)
+}
+
+func NewEventHandler(trUnitFactory TransactionalUnitFactory, eventSender storedevent.Sender, parser IntegrationEventParser) integrationevent.EventHandler {
+	return &eventHandler{
+		trUnitFactory: trUnitFactory,
+		eventSender:   eventSender,
+		parser:        parser,
+	}
+}
+
+type eventHandler struct {
+	trUnitFactory TransactionalUnitFactory
+	eventSender   storedevent.Sender
+	parser        IntegrationEventParser
+}
+
+func (handler *eventHandler) Handle(event integrationevent.EventData) error {
+	parsedEvent, err := handler.parser.ParseIntegrationEvent(event)
+	if err != nil || parsedEvent == nil {
+		return err
+	}
+
+	err = handler.executeInTransaction(func(provider RepositoryProvider) error {
+		eventRepo := provider.ProcessedEventRepository()
+		alreadyProcessed, err := eventRepo.SetEventProcessed(event.UID)
+		if err != nil {
+			return err
+		}
+		if alreadyProcessed {
+			return nil
+		}
+		if err = checkOrderExists(provider, parsedEvent); err != nil {
+			return err
+		}
+
+		switch e := parsedEvent.(type) {
+		case paymentAuthorizedEvent:
+			return handler.handleConfirmationResult(provider, e, paymentResultSetter(e.paymentID), true)
+		case paymentFailedEvent:
+			return handler.handleConfirmationResult(provider, e, paymentFailureSetter(e.paymentID), false)
+		case stockReservedEvent:
+			return handler.handleConfirmationResult(provider, e, (*Order).SetStockReservationResult, true)
+		case stockReservationFailedEvent:
+			return handler.handleConfirmationResult(provider, e, (*Order).SetStockReservationResult, false)
+		case paymentVoidedEvent:
+			if e.expired() {
+				return handler.handleConfirmationResult(provider, e, authorizationExpirySetter, false)
+			}
+			return addEventHistoryRecord(provider, e)
+		case paymentCapturedEvent, paymentRefundedEvent, notificationSentEvent:
+			return addEventHistoryRecord(provider, e)
+		default:
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	handler.eventSender.SendStoredEvents()
+	return nil
+}
+
+// handleConfirmationResult locks the order as payment and stock results for it may be handled concurrently
+func (handler *eventHandler) handleConfirmationResult(
+	provider RepositoryProvider,
+	event OrderEvent,
+	setResult func(order *Order, succeeded bool) (bool, error),
+	succeeded bool,
+) error {
+	repo := provider.OrderRepository()
+	order, err := repo.FindByIDForUpdate(event.OrderID())
+	if err != nil {
+		return err
+	}
+
+	statusChanged, err := setResult(order, succeeded)
+	if err != nil {
+		if errors.Cause(err) == ErrInvalidStatusTransition {
+			// result for already processed order, e.g. payment failure of cancelled order, is kept only in history
+			return addEventHistoryRecord(provider, event)
+		}
+		return err
+	}
+	if err = addEventHistoryRecord(provider, event); err != nil {
+		return err
+	}
+	if !statusChanged {
+		return repo.Store(order)
+	}
+
+	return storeOrderWithStatusEvent(provider, handler.eventSender, order)
+}
+
+// checkOrderExists skips events of payments made without an order of the service, e.g. through the internal payment API,
+// such events can never be applied and retrying them would block the consumer
+func checkOrderExists(provider RepositoryProvider, event OrderEvent) error {
+	_, err := provider.OrderRepository().FindByID(event.OrderID())
+	if errors.Cause(err) == ErrOrderNotFound {
+		return errors.Wrapf(integrationevent.ErrEventSkipped, "order %s not found", string(event.OrderID()))
+	}
+	return err
+}
+
+func paymentResultSetter(paymentID PaymentID) func(order *Order, succeeded bool) (bool, error) {
+	return func(order *Order, succeeded bool) (bool, error) {
+		return order.SetPaymentResult(paymentID, succeeded)
+	}
+}
+
+// paymentFailureSetter fails the payment of paid order, as for paid order billing reports only failed capture
+func paymentFailureSetter(paymentID PaymentID) func(order *Order, succeeded bool) (bool, error) {
+	return func(order *Order, succeeded bool) (bool, error) {
+		if order.Status == OrderStatusPaid {
+			return order.FailPayment()
+		}
+		return order.SetPaymentResult(paymentID, succeeded)
+	}
+}
+
+// authorizationExpirySetter fails the payment of pending order only,
+// expired hold of paid order is still captured if the account has enough funds
+func authorizationExpirySetter(order *Order, _ bool) (bool, error) {
+	if order.Status != OrderStatusPending {
+		return false, errors.WithStack(ErrInvalidStatusTransition)
+	}
+	return order.FailPayment()
+}
+
+func (handler *eventHandler) executeInTransaction(f func(RepositoryProvider) error) (err error) {
+	var trUnit TransactionalUnit
+	trUnit, err = handler.trUnitFactory.NewTransactionalUnit()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = trUnit.Complete(err)
+	}()
+	err = f(trUnit)
+	return err
+}
+
+func addEventHistoryRecord(provider RepositoryProvider, event OrderEvent) error {
+	record, err := newEventHistoryRecord(event)
+	if err != nil {
+		return err
+	}
+	return provider.OrderHistoryRepository().Add(&record)
+}
@@ -1,29 +1,153 @@
 package app
 
 import (
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/uuid"
-	"errors"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 var ErrOrderNotFound = errors.New("order not found")
+var ErrInvalidStatusTransition = errors.New("order status transition not allowed")
 
 type OrderID uuid.UUID
 type UserID uuid.UUID
+type PaymentID uuid.UUID
+
+type OrderStatus int
+
+const (
+	OrderStatusPending   OrderStatus = 0
+	OrderStatusPaid      OrderStatus = 1
+	OrderStatusRejected  OrderStatus = 2
+	OrderStatusCancelled OrderStatus = 3
+	OrderStatusCompleted OrderStatus = 4
+)
+
+// ConfirmationStatus is the result reported by a participant of order confirmation
+type ConfirmationStatus int
+
+const (
+	ConfirmationStatusPending   ConfirmationStatus = 0
+	ConfirmationStatusSucceeded ConfirmationStatus = 1
+	ConfirmationStatusFailed    ConfirmationStatus = 2
+)
+
+var allowedStatusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending: {OrderStatusPaid, OrderStatusRejected},
+	OrderStatusPaid:    {OrderStatusCancelled, OrderStatusCompleted, OrderStatusRejected},
+}
 
+// Order Price is the amount to pay, Discount is already subtracted from it.
+// Pending order waits for both payment and stock reservation results before it is confirmed or rejected
 type Order struct {
-	ID           OrderID
-	UserID       UserID
-	Price        Price
-	CreationDate time.Time
+	ID            OrderID
+	UserID        UserID
+	Items         []OrderItem
+	Price         money.Money
+	Discount      money.Money
+	PromoCode     PromoCodeID
+	Status        OrderStatus
+	PaymentStatus ConfirmationStatus
+	PaymentID     PaymentID
+	StockStatus   ConfirmationStatus
+	CreationDate  time.Time
+}
+
+// Subtotal returns order price before discount
+func (o *Order) Subtotal() (money.Money, error) {
+	return o.Price.Add(o.Discount)
+}
+
+// SetPaymentResult records billing result with the payment made for the order,
+// statusChanged reports that the order got confirmed or rejected
+func (o *Order) SetPaymentResult(paymentID PaymentID, succeeded bool) (statusChanged bool, err error) {
+	statusChanged, err = o.setConfirmationResult(&o.PaymentStatus, succeeded)
+	if err != nil {
+		return false, err
+	}
+	o.PaymentID = paymentID
+	return statusChanged, nil
+}
+
+// SetStockReservationResult records stock result, statusChanged reports that the order got confirmed or rejected
+func (o *Order) SetStockReservationResult(succeeded bool) (statusChanged bool, err error) {
+	return o.setConfirmationResult(&o.StockStatus, succeeded)
+}
+
+// FailPayment records that authorized payment is gone: the hold of pending order has expired
+// or the payment of paid order could not be captured, so the order is rejected instead of being left unpaid.
+// Billing has already released the hold in both cases, so the rejected order does not ask for payment refund.
+// Pending order waiting for stock reservation is rejected when the stock result is set
+func (o *Order) FailPayment() (statusChanged bool, err error) {
+	if o.PaymentStatus != ConfirmationStatusSucceeded || (o.Status != OrderStatusPending && o.Status != OrderStatusPaid) {
+		return false, errors.WithStack(ErrInvalidStatusTransition)
+	}
+	o.PaymentStatus = ConfirmationStatusFailed
+	if o.StockStatus == ConfirmationStatusPending {
+		return false, nil
+	}
+	return true, o.reject()
+}
+
+// PaymentRefundRequired reports that the order got rejected after its payment has been authorized
+func (o *Order) PaymentRefundRequired() bool {
+	return o.Status == OrderStatusRejected && o.PaymentStatus == ConfirmationStatusSucceeded
+}
+
+func (o *Order) pay() error {
+	return o.changeStatus(OrderStatusPaid)
+}
+
+func (o *Order) reject() error {
+	return o.changeStatus(OrderStatusRejected)
+}
+
+func (o *Order) Cancel() error {
+	return o.changeStatus(OrderStatusCancelled)
+}
+
+func (o *Order) Complete() error {
+	return o.changeStatus(OrderStatusCompleted)
+}
+
+func (o *Order) setConfirmationResult(result *ConfirmationStatus, succeeded bool) (bool, error) {
+	if o.Status != OrderStatusPending || *result != ConfirmationStatusPending {
+		return false, errors.WithStack(ErrInvalidStatusTransition)
+	}
+	*result = ConfirmationStatusFailed
+	if succeeded {
+		*result = ConfirmationStatusSucceeded
+	}
+
+	if o.PaymentStatus == ConfirmationStatusPending || o.StockStatus == ConfirmationStatusPending {
+		return false, nil
+	}
+	if o.PaymentStatus == ConfirmationStatusSucceeded && o.StockStatus == ConfirmationStatusSucceeded {
+		return true, o.pay()
+	}
+	return true, o.reject()
+}
+
+func (o *Order) changeStatus(status OrderStatus) error {
+	for _, allowedStatus := range allowedStatusTransitions[o.Status] {
+		if allowedStatus == status {
+			o.Status = status
+			return nil
+		}
+	}
+	return errors.WithStack(ErrInvalidStatusTransition)
 }
 
 type OrderRepositoryRead interface {
 	FindByID(id OrderID) (*Order, error)
-	FindAllByUserID(userID UserID) ([]Order, error)
+	// FindList returns orders matching spec, fetching at most spec.Limit + 1 orders to detect the next page
+	FindList(spec OrderListSpec) ([]Order, error)
 }
 
 type OrderRepository interface {
 	OrderRepositoryRead
+	FindByIDForUpdate(id OrderID) (*Order, error)
 	Store(order *Order) error
 }
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/uuid"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestSetConfirmationResults(t *testing.T) {
+	testCases := []struct {
+		name string
+		// paymentFirst sets payment result before stock result
+		paymentFirst           bool
+		paymentSucceeded       bool
+		stockSucceeded         bool
+		expectedStatus         OrderStatus
+		expectedRefundRequired bool
+	}{
+		{
+			name:             "both succeeded",
+			paymentFirst:     true,
+			paymentSucceeded: true,
+			stockSucceeded:   true,
+			expectedStatus:   OrderStatusPaid,
+		},
+		{
+			name:             "both succeeded with stock first",
+			paymentSucceeded: true,
+			stockSucceeded:   true,
+			expectedStatus:   OrderStatusPaid,
+		},
+		{
+			name:                   "stock failed after payment",
+			paymentFirst:           true,
+			paymentSucceeded:       true,
+			expectedStatus:         OrderStatusRejected,
+			expectedRefundRequired: true,
+		},
+		{
+			name:                   "payment succeeded after stock failed",
+			paymentSucceeded:       true,
+			expectedStatus:         OrderStatusRejected,
+			expectedRefundRequired: true,
+		},
+		{
+			name:           "payment failed",
+			paymentFirst:   true,
+			stockSucceeded: true,
+			expectedStatus: OrderStatusRejected,
+		},
+		{
+			name:           "both failed",
+			expectedStatus: OrderStatusRejected,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			order := Order{}
+			paymentID := PaymentID(uuid.GenerateNew())
+			setPayment := func() (bool, error) {
+				return order.SetPaymentResult(paymentID, testCase.paymentSucceeded)
+			}
+			setStock := func() (bool, error) {
+				return order.SetStockReservationResult(testCase.stockSucceeded)
+			}
+			first, second := setStock, setPayment
+			if testCase.paymentFirst {
+				first, second = setPayment, setStock
+			}
+
+			statusChanged, err := first()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statusChanged || order.Status != OrderStatusPending {
+				t.Fatalf("expected pending order after first result, got status %d", order.Status)
+			}
+			statusChanged, err = second()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !statusChanged || order.Status != testCase.expectedStatus {
+				t.Errorf("expected status %d, got %d", testCase.expectedStatus, order.Status)
+			}
+			if order.PaymentID != paymentID {
+				t.Errorf("expected payment %s, got %s", paymentID, order.PaymentID)
+			}
+			if order.PaymentRefundRequired() != testCase.expectedRefundRequired {
+				t.Errorf("expected payment refund required %v", testCase.expectedRefundRequired)
+			}
+
+			if _, err = order.SetPaymentResult(paymentID, true); errors.Cause(err) != ErrInvalidStatusTransition {
+				t.Errorf("expected repeated payment result error %v, got %v", ErrInvalidStatusTransition, err)
+			}
+			if _, err = order.SetStockReservationResult(true); errors.Cause(err) != ErrInvalidStatusTransition {
+				t.Errorf("expected repeated stock result error %v, got %v", ErrInvalidStatusTransition, err)
+			}
+		})
+	}
+}
+
+func TestChangeStatus(t *testing.T) {
+	allStatuses := []OrderStatus{
+		OrderStatusPending,
+		OrderStatusPaid,
+		OrderStatusRejected,
+		OrderStatusCancelled,
+		OrderStatusCompleted,
+	}
+	testCases := []struct {
+		name            string
+		change          func(o *Order) error
+		expectedStatus  OrderStatus
+		allowedStatuses []OrderStatus
+	}{
+		{
+			name:            "cancel",
+			change:          (*Order).Cancel,
+			expectedStatus:  OrderStatusCancelled,
+			allowedStatuses: []OrderStatus{OrderStatusPaid},
+		},
+		{
+			name:            "complete",
+			change:          (*Order).Complete,
+			expectedStatus:  OrderStatusCompleted,
+			allowedStatuses: []OrderStatus{OrderStatusPaid},
+		},
+	}
+
+	for _, testCase := range testCases {
+		for _, status := range allStatuses {
+			allowed := false
+			for _, allowedStatus := range testCase.allowedStatuses {
+				allowed = allowed || allowedStatus == status
+			}
+			t.Run(fmt.Sprintf("%s order with status %d", testCase.name, status), func(t *testing.T) {
+				order := Order{Status: status}
+				err := testCase.change(&order)
+				if !allowed {
+					if errors.Cause(err) != ErrInvalidStatusTransition || order.Status != status {
+						t.Errorf("expected error %v with status %d, got %v with status %d", ErrInvalidStatusTransition, status, err, order.Status)
+					}
+					return
+				}
+				if err != nil || order.Status != testCase.expectedStatus {
+					t.Errorf("expected status %d, got %d with error %v", testCase.expectedStatus, order.Status, err)
+				}
+			})
+		}
+	}
+}
+
+func TestFailPayment(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		order                 Order
+		expectedErr           error
+		expectedStatusChanged bool
+		expectedStatus        OrderStatus
+		expectedPaymentStatus ConfirmationStatus
+	}{
+		{
+			name: "capture failed for paid order",
+			order: Order{
+				Status:        OrderStatusPaid,
+				PaymentStatus: ConfirmationStatusSucceeded,
+				StockStatus:   ConfirmationStatusSucceeded,
+			},
+			expectedStatusChanged: true,
+			expectedStatus:        OrderStatusRejected,
+			expectedPaymentStatus: ConfirmationStatusFailed,
+		},
+		{
+			name: "hold expired for pending order with reserved stock",
+			order: Order{
+				Status:        OrderStatusPending,
+				PaymentStatus: ConfirmationStatusSucceeded,
+				StockStatus:   ConfirmationStatusSucceeded,
+			},
+			expectedStatusChanged: true,
+			expectedStatus:        OrderStatusRejected,
+			expectedPaymentStatus: ConfirmationStatusFailed,
+		},
+		{
+			name: "hold expired for pending order waiting for stock",
+			order: Order{
+				Status:        OrderStatusPending,
+				PaymentStatus: ConfirmationStatusSucceeded,
+				StockStatus:   ConfirmationStatusPending,
+			},
+			expectedStatus:        OrderStatusPending,
+			expectedPaymentStatus: ConfirmationStatusFailed,
+		},
+		{
+			name: "payment not authorized",
+			order: Order{
+				Status:        OrderStatusPending,
+				PaymentStatus: ConfirmationStatusPending,
+				StockStatus:   ConfirmationStatusSucceeded,
+			},
+			expectedErr:           ErrInvalidStatusTransition,
+			expectedStatus:        OrderStatusPending,
+			expectedPaymentStatus: ConfirmationStatusPending,
+		},
+		{
+			name: "cancelled order",
+			order: Order{
+				Status:        OrderStatusCancelled,
+				PaymentStatus: ConfirmationStatusSucceeded,
+				StockStatus:   ConfirmationStatusSucceeded,
+			},
+			expectedErr:           ErrInvalidStatusTransition,
+			expectedStatus:        OrderStatusCancelled,
+			expectedPaymentStatus: ConfirmationStatusSucceeded,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			order := testCase.order
+			statusChanged, err := order.FailPayment()
+			if errors.Cause(err) != testCase.expectedErr {
+				t.Fatalf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+			if statusChanged != testCase.expectedStatusChanged {
+				t.Errorf("expected status changed %v, got %v", testCase.expectedStatusChanged, statusChanged)
+			}
+			if order.Status != testCase.expectedStatus || order.PaymentStatus != testCase.expectedPaymentStatus {
+				t.Errorf("expected status %d with payment status %d, got %d with %d",
+					testCase.expectedStatus, testCase.expectedPaymentStatus, order.Status, order.PaymentStatus)
+			}
+			if order.PaymentRefundRequired() {
+				// billing releases the hold of the failed payment itself
+				t.Error("expected no payment refund for failed payment")
+			}
+		})
+	}
+}
+
+func TestFailPaymentOfPendingOrderRejectsItOnStockResult(t *testing.T) {
+	order := Order{
+		Status:        OrderStatusPending,
+		PaymentStatus: ConfirmationStatusSucceeded,
+		StockStatus:   ConfirmationStatusPending,
+	}
+	if _, err := order.FailPayment(); err != nil {
+		t.Fatal(err)
+	}
+	statusChanged, err := order.SetStockReservationResult(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statusChanged || order.Status != OrderStatusRejected {
+		t.Errorf("expected order rejected, got status %d", order.Status)
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type OrderHistoryEventType string
+
+const (
+	OrderHistoryCreated                OrderHistoryEventType = "order_created"
+	OrderHistoryPaymentAuthorized      OrderHistoryEventType = "payment_authorized"
+	OrderHistoryPaymentFailed          OrderHistoryEventType = "payment_failed"
+	OrderHistoryPaymentCaptured        OrderHistoryEventType = "payment_captured"
+	OrderHistoryPaymentVoided          OrderHistoryEventType = "payment_voided"
+	OrderHistoryPaymentRefunded        OrderHistoryEventType = "payment_refunded"
+	OrderHistoryStockReserved          OrderHistoryEventType = "stock_reserved"
+	OrderHistoryStockReservationFailed OrderHistoryEventType = "stock_reservation_failed"
+	OrderHistoryConfirmed              OrderHistoryEventType = "order_confirmed"
+	OrderHistoryRejected               OrderHistoryEventType = "order_rejected"
+	OrderHistoryCancelled              OrderHistoryEventType = "order_cancelled"
+	OrderHistoryCompleted              OrderHistoryEventType = "order_completed"
+	OrderHistoryNotificationSent       OrderHistoryEventType = "notification_sent"
+)
+
+// Actor is the user or the service that caused the order history event
+type Actor string
+
+const (
+	ActorUser         Actor = "user"
+	ActorAdmin        Actor = "admin"
+	ActorOrder        Actor = "order"
+	ActorBilling      Actor = "billing"
+	ActorStock        Actor = "stock"
+	ActorNotification Actor = "notification"
+)
+
+type OrderHistoryRecord struct {
+	OrderID      OrderID
+	Type         OrderHistoryEventType
+	Actor        Actor
+	Details      string
+	CreationDate time.Time
+}
+
+type OrderHistoryRepositoryRead interface {
+	// FindByOrderID returns order history in chronological order
+	FindByOrderID(id OrderID) ([]OrderHistoryRecord, error)
+}
+
+type OrderHistoryRepository interface {
+	OrderHistoryRepositoryRead
+	Add(record *OrderHistoryRecord) error
+}
+
+func newStatusHistoryRecord(order *Order) (OrderHistoryRecord, error) {
+	record := OrderHistoryRecord{
+		OrderID:      order.ID,
+		CreationDate: time.Now(),
+	}
+	switch order.Status {
+	case OrderStatusPending:
+		record.Type, record.Actor = OrderHistoryCreated, ActorUser
+		if order.PromoCode != "" {
+			record.Details = fmt.Sprintf("promo code %s", order.PromoCode)
+		}
+	case OrderStatusPaid:
+		record.Type, record.Actor = OrderHistoryConfirmed, ActorOrder
+	case OrderStatusRejected:
+		record.Type, record.Actor = OrderHistoryRejected, ActorOrder
+		if order.PaymentRefundRequired() {
+			record.Details = "payment release requested"
+		}
+	case OrderStatusCancelled:
+		record.Type, record.Actor = OrderHistoryCancelled, ActorUser
+	case OrderStatusCompleted:
+		record.Type, record.Actor = OrderHistoryCompleted, ActorAdmin
+	default:
+		return OrderHistoryRecord{}, errors.New("unknown order status")
+	}
+	return record, nil
+}
+
+func newEventHistoryRecord(event OrderEvent) (OrderHistoryRecord, error) {
+	record := OrderHistoryRecord{
+		OrderID:      event.OrderID(),
+		CreationDate: time.Now(),
+	}
+	switch e := event.(type) {
+	case paymentAuthorizedEvent:
+		record.Type, record.Actor = OrderHistoryPaymentAuthorized, ActorBilling
+	case paymentFailedEvent:
+		record.Type, record.Actor, record.Details = OrderHistoryPaymentFailed, ActorBilling, e.reason
+	case paymentCapturedEvent:
+		record.Type, record.Actor = OrderHistoryPaymentCaptured, ActorBilling
+	case paymentVoidedEvent:
+		record.Type, record.Actor, record.Details = OrderHistoryPaymentVoided, ActorBilling, e.reason
+	case paymentRefundedEvent:
+		record.Type, record.Actor, record.Details = OrderHistoryPaymentRefunded, ActorBilling, e.amount.String()
+		if e.fullyRefunded {
+			record.Details += ", fully refunded"
+		}
+	case stockReservedEvent:
+		record.Type, record.Actor = OrderHistoryStockReserved, ActorStock
+	case stockReservationFailedEvent:
+		record.Type, record.Actor = OrderHistoryStockReservationFailed, ActorStock
+		record.Details = fmt.Sprintf("%s, sku '%s'", e.reason, e.sku)
+	case notificationSentEvent:
+		record.Type, record.Actor, record.Details = OrderHistoryNotificationSent, ActorNotification, e.notificationType
+	default:
+		return OrderHistoryRecord{}, errors.New("unknown order event")
+	}
+	return record, nil
+}
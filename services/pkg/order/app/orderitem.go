@@ -0,0 +1,114 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+
+	"github.com/pkg/errors"
+)
+
+var ErrEmptyOrder = errors.New("order should contain at least one item")
+var ErrInvalidSKU = errors.New("order item sku should not be empty")
+var ErrDuplicateSKU = errors.New("order item sku should be unique within order")
+var ErrInvalidQuantity = errors.New("order item quantity should be positive value")
+
+const maxSKULen = 64
+
+type SKU string
+
+// OrderLine is ordered product, its name and price are taken from the catalog
+type OrderLine struct {
+	SKU      SKU
+	Quantity uint64
+}
+
+// OrderItem is a snapshot of catalog product at the moment of order creation
+type OrderItem struct {
+	SKU       SKU
+	Name      string
+	Quantity  uint64
+	UnitPrice money.Money
+}
+
+func (item *OrderItem) TotalPrice() (money.Money, error) {
+	return item.UnitPrice.Mul(item.Quantity)
+}
+
+func validateSKU(sku SKU) error {
+	if sku == "" || len(sku) > maxSKULen {
+		return errors.WithStack(ErrInvalidSKU)
+	}
+	return nil
+}
+
+func validateOrderLines(lines []OrderLine) error {
+	if len(lines) == 0 {
+		return errors.WithStack(ErrEmptyOrder)
+	}
+	skus := make(map[SKU]struct{}, len(lines))
+	for _, line := range lines {
+		if err := validateSKU(line.SKU); err != nil {
+			return err
+		}
+		if _, ok := skus[line.SKU]; ok {
+			return errors.Wrapf(ErrDuplicateSKU, "sku '%s'", line.SKU)
+		}
+		skus[line.SKU] = struct{}{}
+		if line.Quantity == 0 {
+			return errors.WithStack(ErrInvalidQuantity)
+		}
+	}
+	return nil
+}
+
+// resolveOrderItems takes current catalog name and price of validated order lines
+func resolveOrderItems(repo ProductRepositoryRead, lines []OrderLine) ([]OrderItem, error) {
+	skus := make([]SKU, 0, len(lines))
+	for _, line := range lines {
+		skus = append(skus, line.SKU)
+	}
+	products, err := repo.FindBySKUs(skus)
+	if err != nil {
+		return nil, err
+	}
+	productsBySKU := make(map[SKU]Product, len(products))
+	for _, product := range products {
+		productsBySKU[product.SKU] = product
+	}
+
+	items := make([]OrderItem, 0, len(lines))
+	for _, line := range lines {
+		product, ok := productsBySKU[line.SKU]
+		if !ok {
+			return nil, errors.Wrapf(ErrProductNotFound, "sku '%s'", line.SKU)
+		}
+		if !product.Active {
+			return nil, errors.Wrapf(ErrProductInactive, "sku '%s'", line.SKU)
+		}
+		if len(items) > 0 && product.Price.Currency() != items[0].UnitPrice.Currency() {
+			return nil, errors.Wrapf(money.ErrCurrencyMismatch, "sku '%s'", line.SKU)
+		}
+		items = append(items, OrderItem{
+			SKU:       product.SKU,
+			Name:      product.Name,
+			Quantity:  line.Quantity,
+			UnitPrice: product.Price,
+		})
+	}
+	return items, nil
+}
+
+// calculateTotalPrice expects items with the same currency
+func calculateTotalPrice(items []OrderItem) (money.Money, error) {
+	total := money.Zero(items[0].UnitPrice.Currency())
+	for _, item := range items {
+		itemPrice, err := item.TotalPrice()
+		if err != nil {
+			return money.Money{}, err
+		}
+		total, err = total.Add(itemPrice)
+		if err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidListSpec = errors.New("invalid order list parameters")
+
+const DefaultOrderPageSize = 20
+const MaxOrderPageSize = 100
+
+type OrderSortField int
+
+const (
+	OrderSortByCreationDate OrderSortField = 0
+	OrderSortByPrice        OrderSortField = 1
+)
+
+// OrderCursor points to the last order of the previous page
+type OrderCursor struct {
+	SortField    OrderSortField
+	Descending   bool
+	CreationDate time.Time
+	Price        int64
+	ID           OrderID
+}
+
+type OrderListSpec struct {
+	UserID      UserID
+	Status      *OrderStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Currency    *money.Currency
+	MinPrice    *money.Money
+	MaxPrice    *money.Money
+	SortField   OrderSortField
+	Descending  bool
+	Cursor      *OrderCursor
+	Limit       int
+}
+
+func (spec *OrderListSpec) validate() error {
+	if spec.Limit <= 0 || spec.Limit > MaxOrderPageSize {
+		return errors.Wrapf(ErrInvalidListSpec, "limit should be in range 1..%d", MaxOrderPageSize)
+	}
+	if spec.CreatedFrom != nil && spec.CreatedTo != nil && spec.CreatedFrom.After(*spec.CreatedTo) {
+		return errors.Wrap(ErrInvalidListSpec, "creation date range is empty")
+	}
+	for _, price := range []*money.Money{spec.MinPrice, spec.MaxPrice} {
+		if price != nil && (spec.Currency == nil || price.Currency() != *spec.Currency) {
+			return errors.Wrap(ErrInvalidListSpec, "price range requires the same currency filter")
+		}
+	}
+	// prices in different currencies are not comparable, so orders are sorted by price within one currency only
+	if spec.SortField == OrderSortByPrice && spec.Currency == nil {
+		return errors.Wrap(ErrInvalidListSpec, "price sort requires currency filter")
+	}
+	if spec.MinPrice != nil && spec.MaxPrice != nil && spec.MinPrice.MinorUnits() > spec.MaxPrice.MinorUnits() {
+		return errors.Wrap(ErrInvalidListSpec, "price range is empty")
+	}
+	if spec.Cursor != nil && (spec.Cursor.SortField != spec.SortField || spec.Cursor.Descending != spec.Descending) {
+		return errors.Wrap(ErrInvalidListSpec, "cursor does not match sort order")
+	}
+	return nil
+}
+
+type OrderPage struct {
+	Orders     []Order
+	NextCursor *OrderCursor
+}
+
+func newOrderPage(orders []Order, spec *OrderListSpec) OrderPage {
+	if len(orders) <= spec.Limit {
+		return OrderPage{Orders: orders}
+	}
+	orders = orders[:spec.Limit]
+	last := orders[len(orders)-1]
+	return OrderPage{
+		Orders: orders,
+		NextCursor: &OrderCursor{
+			SortField:    spec.SortField,
+			Descending:   spec.Descending,
+			CreationDate: last.CreationDate,
+			Price:        last.Price.MinorUnits(),
+			ID:           last.ID,
+		},
+	}
+}
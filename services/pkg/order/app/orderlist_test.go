@@ -0,0 +1,84 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestOrderListSpecValidate(t *testing.T) {
+	usd, err := money.ParseCurrency("USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eur, err := money.ParseCurrency("EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	price := func(minorUnits int64, currency money.Currency) *money.Money {
+		m := money.New(minorUnits, currency)
+		return &m
+	}
+
+	testCases := []struct {
+		name        string
+		spec        OrderListSpec
+		expectedErr error
+	}{
+		{
+			name: "date sort without currency",
+			spec: OrderListSpec{SortField: OrderSortByCreationDate, Limit: DefaultOrderPageSize},
+		},
+		{
+			name: "price sort with currency",
+			spec: OrderListSpec{SortField: OrderSortByPrice, Currency: &usd, Limit: DefaultOrderPageSize},
+		},
+		{
+			name:        "price sort without currency",
+			spec:        OrderListSpec{SortField: OrderSortByPrice, Limit: DefaultOrderPageSize},
+			expectedErr: ErrInvalidListSpec,
+		},
+		{
+			name: "price cursor without currency",
+			spec: OrderListSpec{
+				SortField: OrderSortByPrice,
+				Cursor:    &OrderCursor{SortField: OrderSortByPrice, Price: 1000},
+				Limit:     DefaultOrderPageSize,
+			},
+			expectedErr: ErrInvalidListSpec,
+		},
+		{
+			name: "price range with currency",
+			spec: OrderListSpec{Currency: &usd, MinPrice: price(100, usd), MaxPrice: price(200, usd), Limit: DefaultOrderPageSize},
+		},
+		{
+			name:        "price range without currency",
+			spec:        OrderListSpec{MinPrice: price(100, usd), Limit: DefaultOrderPageSize},
+			expectedErr: ErrInvalidListSpec,
+		},
+		{
+			name:        "price range in another currency",
+			spec:        OrderListSpec{Currency: &eur, MaxPrice: price(200, usd), Limit: DefaultOrderPageSize},
+			expectedErr: ErrInvalidListSpec,
+		},
+		{
+			name:        "empty price range",
+			spec:        OrderListSpec{Currency: &usd, MinPrice: price(200, usd), MaxPrice: price(100, usd), Limit: DefaultOrderPageSize},
+			expectedErr: ErrInvalidListSpec,
+		},
+		{
+			name:        "limit out of range",
+			spec:        OrderListSpec{Limit: MaxOrderPageSize + 1},
+			expectedErr: ErrInvalidListSpec,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if err := testCase.spec.validate(); errors.Cause(err) != testCase.expectedErr {
+				t.Errorf("expected error %v, got %v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
@@ -1,95 +1,122 @@
 package app
 
 import (
-	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/storedevent"
 	"arch-homework/pkg/common/app/uuid"
 
-	"github.com/pkg/errors"
-
 	"time"
 )
 
-var ErrPaymentFailed = errors.New("order payment failed")
-var ErrAlreadyProcessed = errors.New("request with this id already processed")
-
-func NewOrderService(dbDependency DBDependency, eventSender storedevent.Sender, billingClient BillingClient) *OrderService {
+func NewOrderService(dbDependency DBDependency, eventSender storedevent.Sender) *OrderService {
 	return &OrderService{
-		readRepo:      dbDependency.OrderRepositoryRead(),
-		trUnitFactory: dbDependency,
-		eventSender:   eventSender,
-		billingClient: billingClient,
+		readRepo:        dbDependency.OrderRepositoryRead(),
+		historyReadRepo: dbDependency.OrderHistoryRepositoryRead(),
+		trUnitFactory:   dbDependency,
+		eventSender:     eventSender,
 	}
 }
 
 type OrderService struct {
-	readRepo      OrderRepositoryRead
-	trUnitFactory TransactionalUnitFactory
-	eventSender   storedevent.Sender
-	billingClient BillingClient
+	readRepo        OrderRepositoryRead
+	historyReadRepo OrderHistoryRepositoryRead
+	trUnitFactory   TransactionalUnitFactory
+	eventSender     storedevent.Sender
 }
 
-func (s *OrderService) Create(requestID RequestID, userID UserID, priceFloat float64) (OrderID, error) {
-	price, err := PriceFromFloat(priceFloat)
-	if err != nil {
+// Create creates pending order with prices from the catalog,
+// promo code is optional and its redemption is stored within the order transaction,
+// retried requests are replayed by the idempotency middleware and do not reach the service
+func (s *OrderService) Create(userID UserID, lines []OrderLine, promoCode PromoCodeID) (OrderID, error) {
+	if err := validateOrderLines(lines); err != nil {
 		return "", err
 	}
 	id := OrderID(uuid.GenerateNew())
 
-	order := Order{
-		ID:           id,
-		UserID:       userID,
-		Price:        price,
-		CreationDate: time.Now(),
-	}
-
-	var paymentSucceeded = false
-
-	err = s.executeInTransaction(func(provider RepositoryProvider) error {
-		eventRepo := provider.ProcessedRequestRepository()
-		alreadyProcessed, err := eventRepo.SetRequestProcessed(requestID)
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		items, err := resolveOrderItems(provider.ProductRepository(), lines)
 		if err != nil {
 			return err
 		}
-		if alreadyProcessed {
-			return ErrAlreadyProcessed
-		}
-
-		paymentSucceeded, err = s.billingClient.ProcessOrderPayment(userID, price)
+		price, err := calculateTotalPrice(items)
 		if err != nil {
 			return err
 		}
-
-		var event integrationevent.EventData
-		if paymentSucceeded {
-			err2 := provider.OrderRepository().Store(&order)
-			if err2 != nil {
-				return err2
-			}
-			event = NewOrderConfirmedEvent(id, userID)
-		} else {
-			event = NewOrderRejectedEvent(id, userID)
+		order := Order{
+			ID:           id,
+			UserID:       userID,
+			Items:        items,
+			Price:        price,
+			Discount:     money.Zero(price.Currency()),
+			PromoCode:    promoCode,
+			Status:       OrderStatusPending,
+			CreationDate: time.Now(),
 		}
 
-		err2 := provider.EventStore().Add(event)
-		if err2 != nil {
-			return err2
+		if order.PromoCode == "" {
+			return storeOrderWithStatusEvent(provider, s.eventSender, &order)
 		}
-		s.eventSender.EventStored(event.UID)
-
-		return nil
+		promoCodeRepo := provider.PromoCodeRepository()
+		if err = applyPromoCode(promoCodeRepo, &order); err != nil {
+			return err
+		}
+		if err = storeOrderWithStatusEvent(provider, s.eventSender, &order); err != nil {
+			return err
+		}
+		return promoCodeRepo.AddRedemption(PromoCodeRedemption{
+			Code:     order.PromoCode,
+			UserID:   order.UserID,
+			OrderID:  order.ID,
+			Discount: order.Discount,
+		})
 	})
 	if err != nil {
 		return "", err
 	}
 
 	s.eventSender.SendStoredEvents()
+	return id, nil
+}
+
+func (s *OrderService) Cancel(userID UserID, id OrderID) error {
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		order, err := provider.OrderRepository().FindByID(id)
+		if err != nil {
+			return err
+		}
+		if order.UserID != userID {
+			return ErrOrderNotFound
+		}
+		if err = order.Cancel(); err != nil {
+			return err
+		}
+		return storeOrderWithStatusEvent(provider, s.eventSender, order)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.eventSender.SendStoredEvents()
+	return nil
+}
 
-	if !paymentSucceeded {
-		return id, errors.WithStack(ErrPaymentFailed)
+func (s *OrderService) Complete(id OrderID) error {
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		order, err := provider.OrderRepository().FindByID(id)
+		if err != nil {
+			return err
+		}
+		if err = order.Complete(); err != nil {
+			return err
+		}
+		return storeOrderWithStatusEvent(provider, s.eventSender, order)
+	})
+	if err != nil {
+		return err
 	}
 
-	return id, err
+	s.eventSender.SendStoredEvents()
+	return nil
 }
 
 func (s *OrderService) Get(userID UserID, id OrderID) (*Order, error) {
@@ -103,8 +130,23 @@ func (s *OrderService) Get(userID UserID, id OrderID) (*Order, error) {
 	return order, nil
 }
 
-func (s *OrderService) FindAll(userID UserID) ([]Order, error) {
-	return s.readRepo.FindAllByUserID(userID)
+// History returns state changes and related events of the user order
+func (s *OrderService) History(userID UserID, id OrderID) ([]OrderHistoryRecord, error) {
+	if _, err := s.Get(userID, id); err != nil {
+		return nil, err
+	}
+	return s.historyReadRepo.FindByOrderID(id)
+}
+
+func (s *OrderService) FindList(spec OrderListSpec) (OrderPage, error) {
+	if err := spec.validate(); err != nil {
+		return OrderPage{}, err
+	}
+	orders, err := s.readRepo.FindList(spec)
+	if err != nil {
+		return OrderPage{}, err
+	}
+	return newOrderPage(orders, &spec), nil
 }
 
 func (s *OrderService) executeInTransaction(f func(RepositoryProvider) error) (err error) {
@@ -119,3 +161,81 @@ func (s *OrderService) executeInTransaction(f func(RepositoryProvider) error) (e
 	err = f(trUnit)
 	return err
 }
+
+// storeOrderWithStatusEvent saves the order, records its status in the history
+// and publishes the event matching its current status
+func storeOrderWithStatusEvent(provider RepositoryProvider, eventSender storedevent.Sender, order *Order) error {
+	err := provider.OrderRepository().Store(order)
+	if err != nil {
+		return err
+	}
+	if order.Status == OrderStatusRejected || order.Status == OrderStatusCancelled {
+		if err = releasePromoCode(provider.PromoCodeRepository(), order); err != nil {
+			return err
+		}
+	}
+
+	record, err := newStatusHistoryRecord(order)
+	if err != nil {
+		return err
+	}
+	if err = provider.OrderHistoryRepository().Add(&record); err != nil {
+		return err
+	}
+
+	event, err := newOrderStatusChangedEvent(order)
+	if err != nil {
+		return err
+	}
+	err = provider.EventStore().Add(event)
+	if err != nil {
+		return err
+	}
+	eventSender.EventStored(event.UID)
+	return nil
+}
+
+func applyPromoCode(repo PromoCodeRepository, order *Order) error {
+	promoCode, err := repo.FindByCodeForUpdate(order.PromoCode)
+	if err != nil {
+		return err
+	}
+	userRedemptions, err := repo.CountUserRedemptions(promoCode.Code, order.UserID)
+	if err != nil {
+		return err
+	}
+	discount, err := promoCode.discount(order.Price, userRedemptions, time.Now())
+	if err != nil {
+		return err
+	}
+	price, err := order.Price.Sub(discount)
+	if err != nil {
+		return err
+	}
+	order.Price = price
+	order.Discount = discount
+
+	promoCode.RedemptionCount++
+	return repo.Store(promoCode)
+}
+
+// releasePromoCode returns the redemption of the rejected or cancelled order to promo code limits,
+// the redemption is released once however many times the order is stored
+func releasePromoCode(repo PromoCodeRepository, order *Order) error {
+	if order.PromoCode == "" {
+		return nil
+	}
+	// promo code is locked before the redemption in the same order as on order creation
+	promoCode, err := repo.FindByCodeForUpdate(order.PromoCode)
+	if err != nil {
+		return err
+	}
+	removed, err := repo.RemoveRedemption(order.ID)
+	if err != nil || !removed {
+		return err
+	}
+	if promoCode.RedemptionCount > 0 {
+		promoCode.RedemptionCount--
+	}
+	return repo.Store(promoCode)
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrProductNotFound = errors.New("product not found")
+var ErrProductAlreadyExists = errors.New("product already exists")
+var ErrProductInactive = errors.New("product is not available for ordering")
+var ErrInvalidProduct = errors.New("invalid product")
+
+// Product is catalog entry, orders keep a snapshot of product name and price
+type Product struct {
+	SKU          SKU
+	Name         string
+	Price        money.Money
+	Active       bool
+	CreationDate time.Time
+	UpdateDate   time.Time
+}
+
+type ProductRepositoryRead interface {
+	FindBySKU(sku SKU) (*Product, error)
+	FindBySKUs(skus []SKU) ([]Product, error)
+	FindAll(activeOnly bool) ([]Product, error)
+}
+
+type ProductRepository interface {
+	ProductRepositoryRead
+	Store(product *Product) error
+	Remove(sku SKU) error
+}
+
+func (p *Product) validate() error {
+	if err := validateSKU(p.SKU); err != nil {
+		return err
+	}
+	if p.Name == "" {
+		return errors.Wrap(ErrInvalidProduct, "name should not be empty")
+	}
+	if !p.Price.IsPositive() {
+		return errors.Wrap(ErrInvalidProduct, "price should be positive value")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/money"
+
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrPromoCodeNotFound = errors.New("promo code not found")
+var ErrPromoCodeAlreadyExists = errors.New("promo code already exists")
+var ErrInvalidPromoCode = errors.New("invalid promo code")
+var ErrPromoCodeExpired = errors.New("promo code expired")
+var ErrPromoCodeMinOrderTotal = errors.New("order total is less than promo code minimum")
+var ErrPromoCodeUsageLimit = errors.New("promo code usage limit reached")
+
+var promoCodeRegexp = regexp.MustCompile(`^[A-Z0-9_-]{1,64}$`)
+
+type PromoCodeID string
+
+type DiscountType int
+
+const (
+	DiscountTypePercentage DiscountType = 0
+	DiscountTypeFixed      DiscountType = 1
+)
+
+type PromoCode struct {
+	Code          PromoCodeID
+	DiscountType  DiscountType
+	PercentOff    uint64
+	AmountOff     money.Money
+	MinOrderTotal *money.Money
+	ExpiresAt     *time.Time
+	// MaxRedemptions and MaxRedemptionsPerUser equal to 0 mean unlimited usage
+	MaxRedemptions        uint64
+	MaxRedemptionsPerUser uint64
+	RedemptionCount       uint64
+	CreationDate          time.Time
+}
+
+type PromoCodeRedemption struct {
+	Code     PromoCodeID
+	UserID   UserID
+	OrderID  OrderID
+	Discount money.Money
+}
+
+type PromoCodeRepositoryRead interface {
+	FindByCode(code PromoCodeID) (*PromoCode, error)
+}
+
+type PromoCodeRepository interface {
+	PromoCodeRepositoryRead
+	// FindByCodeForUpdate locks promo code until the end of transaction
+	FindByCodeForUpdate(code PromoCodeID) (*PromoCode, error)
+	Store(promoCode *PromoCode) error
+	CountUserRedemptions(code PromoCodeID, userID UserID) (uint64, error)
+	AddRedemption(redemption PromoCodeRedemption) error
+	// RemoveRedemption returns false when the order has no redemption
+	RemoveRedemption(orderID OrderID) (removed bool, err error)
+}
+
+func NormalizePromoCode(code string) (PromoCodeID, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if !promoCodeRegexp.MatchString(normalized) {
+		return "", errors.WithStack(ErrInvalidPromoCode)
+	}
+	return PromoCodeID(normalized), nil
+}
+
+func (p *PromoCode) validate() error {
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		if p.PercentOff == 0 || p.PercentOff > 100 {
+			return errors.Wrap(ErrInvalidPromoCode, "percent off should be in range 1..100")
+		}
+	case DiscountTypeFixed:
+		if !p.AmountOff.IsPositive() {
+			return errors.Wrap(ErrInvalidPromoCode, "amount off should be positive value")
+		}
+		if p.MinOrderTotal != nil && p.MinOrderTotal.Currency() != p.AmountOff.Currency() {
+			return errors.Wrap(money.ErrCurrencyMismatch, "minimum order total")
+		}
+	default:
+		return errors.Wrap(ErrInvalidPromoCode, "unknown discount type")
+	}
+	if p.MinOrderTotal != nil && p.MinOrderTotal.IsNegative() {
+		return errors.Wrap(ErrInvalidPromoCode, "minimum order total should not be negative")
+	}
+	return nil
+}
+
+// discount checks promo code restrictions and returns discount for the order total,
+// discount never exceeds the total
+func (p *PromoCode) discount(total money.Money, userRedemptions uint64, now time.Time) (money.Money, error) {
+	if p.ExpiresAt != nil && !now.Before(*p.ExpiresAt) {
+		return money.Money{}, errors.WithStack(ErrPromoCodeExpired)
+	}
+	if p.MaxRedemptions != 0 && p.RedemptionCount >= p.MaxRedemptions {
+		return money.Money{}, errors.WithStack(ErrPromoCodeUsageLimit)
+	}
+	if p.MaxRedemptionsPerUser != 0 && userRedemptions >= p.MaxRedemptionsPerUser {
+		return money.Money{}, errors.Wrap(ErrPromoCodeUsageLimit, "per user limit")
+	}
+	if p.MinOrderTotal != nil {
+		cmp, err := total.Cmp(*p.MinOrderTotal)
+		if err != nil {
+			return money.Money{}, err
+		}
+		if cmp < 0 {
+			return money.Money{}, errors.WithStack(ErrPromoCodeMinOrderTotal)
+		}
+	}
+
+	if p.DiscountType == DiscountTypePercentage {
+		return total.Percentage(p.PercentOff)
+	}
+	cmp, err := total.Cmp(p.AmountOff)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if cmp < 0 {
+		return total, nil
+	}
+	return p.AmountOff, nil
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewPromoCodeService(dbDependency DBDependency) *PromoCodeService {
+	return &PromoCodeService{
+		readRepo:      dbDependency.PromoCodeRepositoryRead(),
+		trUnitFactory: dbDependency,
+	}
+}
+
+type PromoCodeService struct {
+	readRepo      PromoCodeRepositoryRead
+	trUnitFactory TransactionalUnitFactory
+}
+
+func (s *PromoCodeService) Create(promoCode PromoCode) error {
+	if err := promoCode.validate(); err != nil {
+		return err
+	}
+	promoCode.RedemptionCount = 0
+	promoCode.CreationDate = time.Now()
+
+	return s.executeInTransaction(func(provider RepositoryProvider) error {
+		repo := provider.PromoCodeRepository()
+		_, err := repo.FindByCode(promoCode.Code)
+		if err == nil {
+			return errors.WithStack(ErrPromoCodeAlreadyExists)
+		}
+		if errors.Cause(err) != ErrPromoCodeNotFound {
+			return err
+		}
+		return repo.Store(&promoCode)
+	})
+}
+
+func (s *PromoCodeService) Get(code PromoCodeID) (*PromoCode, error) {
+	return s.readRepo.FindByCode(code)
+}
+
+func (s *PromoCodeService) executeInTransaction(f func(RepositoryProvider) error) (err error) {
+	var trUnit TransactionalUnit
+	trUnit, err = s.trUnitFactory.NewTransactionalUnit()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = trUnit.Complete(err)
+	}()
+	err = f(trUnit)
+	return err
+}
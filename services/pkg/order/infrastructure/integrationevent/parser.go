@@ -0,0 +1,174 @@
+package integrationevent
+
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+	"arch-homework/pkg/order/app"
+
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// typePaymentSucceeded is sent by billing for payments captured without authorization,
+// including payments of orders created before payments were authorized and captured separately
+const typePaymentSucceeded = "billing.payment_succeeded"
+const typePaymentAuthorized = "billing.payment_authorized"
+const typePaymentFailed = "billing.payment_failed"
+const typePaymentCaptured = "billing.payment_captured"
+const typePaymentVoided = "billing.payment_voided"
+const typeRefundCompleted = "billing.refund_completed"
+const typeStockReserved = "stock.stock_reserved"
+const typeStockReservationFailed = "stock.stock_reservation_failed"
+const typeNotificationSent = "notification.notification_sent"
+
+func NewEventParser() app.IntegrationEventParser {
+	return eventParser{}
+}
+
+type eventParser struct {
+}
+
+func (e eventParser) ParseIntegrationEvent(event integrationevent.EventData) (app.OrderEvent, error) {
+	switch event.Type {
+	case typePaymentSucceeded, typePaymentAuthorized:
+		return parsePaymentAuthorizedEvent(event.Body)
+	case typePaymentFailed:
+		return parsePaymentFailedEvent(event.Body)
+	case typePaymentCaptured:
+		return parsePaymentCapturedEvent(event.Body)
+	case typePaymentVoided:
+		return parsePaymentVoidedEvent(event.Body)
+	case typeRefundCompleted:
+		return parseRefundCompletedEvent(event.Body)
+	case typeStockReserved:
+		return parseStockReservedEvent(event.Body)
+	case typeStockReservationFailed:
+		return parseStockReservationFailedEvent(event.Body)
+	case typeNotificationSent:
+		return parseNotificationSentEvent(event.Body)
+	default:
+		return nil, nil
+	}
+}
+
+func parsePaymentAuthorizedEvent(strBody string) (app.OrderEvent, error) {
+	body, err := parsePaymentEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewPaymentAuthorizedEvent(app.OrderID(body.OrderID), app.PaymentID(body.PaymentID)), nil
+}
+
+func parsePaymentFailedEvent(strBody string) (app.OrderEvent, error) {
+	body, err := parsePaymentEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewPaymentFailedEvent(app.OrderID(body.OrderID), app.PaymentID(body.PaymentID), body.Reason), nil
+}
+
+func parsePaymentCapturedEvent(strBody string) (app.OrderEvent, error) {
+	body, err := parsePaymentEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewPaymentCapturedEvent(app.OrderID(body.OrderID)), nil
+}
+
+func parsePaymentVoidedEvent(strBody string) (app.OrderEvent, error) {
+	body, err := parsePaymentEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewPaymentVoidedEvent(app.OrderID(body.OrderID), body.Reason), nil
+}
+
+func parseRefundCompletedEvent(strBody string) (app.OrderEvent, error) {
+	var body refundCompletedEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = uuid.ValidateUUID(body.OrderID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return app.NewPaymentRefundedEvent(app.OrderID(body.OrderID), body.Amount, body.FullyRefunded), nil
+}
+
+func parseStockReservedEvent(strBody string) (app.OrderEvent, error) {
+	body, err := parseStockEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewStockReservedEvent(app.OrderID(body.OrderID)), nil
+}
+
+func parseStockReservationFailedEvent(strBody string) (app.OrderEvent, error) {
+	body, err := parseStockEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return app.NewStockReservationFailedEvent(app.OrderID(body.OrderID), body.Reason, app.SKU(body.SKU)), nil
+}
+
+func parseNotificationSentEvent(strBody string) (app.OrderEvent, error) {
+	var body notificationSentEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if body.OrderID == "" {
+		// notification is not related to an order, e.g. account overdraft
+		return nil, nil
+	}
+	if err = uuid.ValidateUUID(body.OrderID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return app.NewNotificationSentEvent(app.OrderID(body.OrderID), body.NotificationType), nil
+}
+
+func parseStockEvent(strBody string) (stockEventBody, error) {
+	var body stockEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return body, errors.WithStack(err)
+	}
+	err = uuid.ValidateUUID(body.OrderID)
+	return body, errors.WithStack(err)
+}
+
+func parsePaymentEvent(strBody string) (paymentEventBody, error) {
+	var body paymentEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return body, errors.WithStack(err)
+	}
+	err = uuid.ValidateUUID(body.OrderID)
+	return body, errors.WithStack(err)
+}
+
+type paymentEventBody struct {
+	OrderID   string `json:"order_id"`
+	UserID    string `json:"user_id"`
+	PaymentID string `json:"payment_id"`
+	Reason    string `json:"reason"`
+}
+
+type refundCompletedEventBody struct {
+	OrderID       string      `json:"order_id"`
+	Amount        money.Money `json:"amount"`
+	FullyRefunded bool        `json:"fully_refunded"`
+}
+
+type stockEventBody struct {
+	OrderID string `json:"order_id"`
+	Reason  string `json:"reason"`
+	SKU     string `json:"sku"`
+}
+
+type notificationSentEventBody struct {
+	OrderID          string `json:"order_id"`
+	NotificationType string `json:"notification_type"`
+}
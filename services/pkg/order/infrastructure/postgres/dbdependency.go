@@ -20,6 +20,18 @@ func (d *dbDependency) OrderRepositoryRead() app.OrderRepositoryRead {
 	return NewOrderRepository(d.client)
 }
 
+func (d *dbDependency) OrderHistoryRepositoryRead() app.OrderHistoryRepositoryRead {
+	return NewOrderHistoryRepository(d.client)
+}
+
+func (d *dbDependency) PromoCodeRepositoryRead() app.PromoCodeRepositoryRead {
+	return NewPromoCodeRepository(d.client)
+}
+
+func (d *dbDependency) ProductRepositoryRead() app.ProductRepositoryRead {
+	return NewProductRepository(d.client)
+}
+
 func (d *dbDependency) NewTransactionalUnit() (app.TransactionalUnit, error) {
 	transaction, err := d.client.BeginTransaction()
 	if err != nil {
@@ -40,8 +52,20 @@ func (t *transactionalUnit) OrderRepository() app.OrderRepository {
 	return NewOrderRepository(t.transaction)
 }
 
-func (t *transactionalUnit) ProcessedRequestRepository() app.ProcessedRequestRepository {
-	return NewProcessedRequestRepository(t.transaction)
+func (t *transactionalUnit) OrderHistoryRepository() app.OrderHistoryRepository {
+	return NewOrderHistoryRepository(t.transaction)
+}
+
+func (t *transactionalUnit) PromoCodeRepository() app.PromoCodeRepository {
+	return NewPromoCodeRepository(t.transaction)
+}
+
+func (t *transactionalUnit) ProductRepository() app.ProductRepository {
+	return NewProductRepository(t.transaction)
+}
+
+func (t *transactionalUnit) ProcessedEventRepository() app.ProcessedEventRepository {
+	return NewProcessedEventRepository(t.transaction)
 }
 
 func (t *transactionalUnit) Complete(err error) error {
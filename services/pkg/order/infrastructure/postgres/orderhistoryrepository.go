@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"arch-homework/pkg/common/infrastructure/postgres"
+	"arch-homework/pkg/order/app"
+)
+
+func NewOrderHistoryRepository(client postgres.Client) app.OrderHistoryRepository {
+	return &orderHistoryRepository{client: client}
+}
+
+type orderHistoryRepository struct {
+	client postgres.Client
+}
+
+func (repo *orderHistoryRepository) Add(record *app.OrderHistoryRecord) error {
+	const query = `
+			INSERT INTO order_history (order_id, type, actor, details, created_at)
+			VALUES (:order_id, :type, :actor, :details, :created_at)
+		`
+
+	recordx := sqlxOrderHistoryRecord{
+		OrderID:      string(record.OrderID),
+		Type:         string(record.Type),
+		Actor:        string(record.Actor),
+		Details:      record.Details,
+		CreationDate: record.CreationDate,
+	}
+
+	_, err := repo.client.NamedExec(query, &recordx)
+	return errors.WithStack(err)
+}
+
+func (repo *orderHistoryRepository) FindByOrderID(id app.OrderID) ([]app.OrderHistoryRecord, error) {
+	const query = `SELECT order_id, type, actor, details, created_at FROM order_history WHERE order_id = $1 ORDER BY created_at, id`
+
+	var records []*sqlxOrderHistoryRecord
+	err := repo.client.Select(&records, query, string(id))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]app.OrderHistoryRecord, 0, len(records))
+	for _, record := range records {
+		res = append(res, app.OrderHistoryRecord{
+			OrderID:      app.OrderID(record.OrderID),
+			Type:         app.OrderHistoryEventType(record.Type),
+			Actor:        app.Actor(record.Actor),
+			Details:      record.Details,
+			CreationDate: record.CreationDate,
+		})
+	}
+	return res, nil
+}
+
+type sqlxOrderHistoryRecord struct {
+	OrderID      string    `db:"order_id"`
+	Type         string    `db:"type"`
+	Actor        string    `db:"actor"`
+	Details      string    `db:"details"`
+	CreationDate time.Time `db:"created_at"`
+}
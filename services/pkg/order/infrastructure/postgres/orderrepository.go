@@ -2,14 +2,20 @@ package postgres
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/infrastructure/postgres"
 	"arch-homework/pkg/order/app"
 )
 
+const selectOrderQuery = `SELECT id, user_id, price, currency, discount, promo_code, status, payment_status, payment_id, stock_status, created_at FROM orders`
+
 func NewOrderRepository(client postgres.Client) app.OrderRepository {
 	return &orderRepository{client: client}
 }
@@ -20,26 +26,46 @@ type orderRepository struct {
 
 func (repo *orderRepository) Store(order *app.Order) error {
 	const query = `
-			INSERT INTO orders (id, user_id, price, created_at)
-			VALUES (:id, :user_id, :price, :created_at)
+			INSERT INTO orders (id, user_id, price, currency, discount, promo_code, status, payment_status, payment_id, stock_status, created_at)
+			VALUES (:id, :user_id, :price, :currency, :discount, :promo_code, :status, :payment_status, :payment_id, :stock_status, :created_at)
 			ON CONFLICT (id) DO UPDATE SET
-				price = excluded.price;
+				price = excluded.price,
+				status = excluded.status,
+				payment_status = excluded.payment_status,
+				payment_id = excluded.payment_id,
+				stock_status = excluded.stock_status;
 		`
 
 	orderx := sqlxOrder{
-		ID:           string(order.ID),
-		UserID:       string(order.UserID),
-		Price:        order.Price.RawValue(),
-		CreationDate: order.CreationDate,
+		ID:            string(order.ID),
+		UserID:        string(order.UserID),
+		Price:         order.Price.MinorUnits(),
+		Currency:      string(order.Price.Currency()),
+		Discount:      order.Discount.MinorUnits(),
+		PromoCode:     string(order.PromoCode),
+		Status:        int(order.Status),
+		PaymentStatus: int(order.PaymentStatus),
+		PaymentID:     string(order.PaymentID),
+		StockStatus:   int(order.StockStatus),
+		CreationDate:  order.CreationDate.UTC(),
 	}
 
 	_, err := repo.client.NamedExec(query, &orderx)
-	return errors.WithStack(err)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return repo.storeItems(order)
 }
 
 func (repo *orderRepository) FindByID(id app.OrderID) (*app.Order, error) {
-	const query = `SELECT id, user_id, price, created_at FROM orders WHERE id = $1`
+	return repo.find(selectOrderQuery+` WHERE id = $1`, id)
+}
 
+func (repo *orderRepository) FindByIDForUpdate(id app.OrderID) (*app.Order, error) {
+	return repo.find(selectOrderQuery+` WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (repo *orderRepository) find(query string, id app.OrderID) (*app.Order, error) {
 	var order sqlxOrder
 	err := repo.client.Get(&order, query, string(id))
 	if err != nil {
@@ -48,38 +74,181 @@ func (repo *orderRepository) FindByID(id app.OrderID) (*app.Order, error) {
 		}
 		return nil, errors.WithStack(err)
 	}
-	res := sqlxOrderToOrder(&order)
-	return &res, nil
+	res, err := repo.withItems([]*sqlxOrder{&order})
+	if err != nil {
+		return nil, err
+	}
+	return &res[0], nil
 }
 
-func (repo *orderRepository) FindAllByUserID(userID app.UserID) ([]app.Order, error) {
-	const query = `SELECT id, user_id, price, created_at FROM orders where user_id = $1`
+func (repo *orderRepository) FindList(spec app.OrderListSpec) ([]app.Order, error) {
+	conditions := []string{"user_id = ?"}
+	params := []interface{}{string(spec.UserID)}
+	if spec.Status != nil {
+		conditions = append(conditions, "status = ?")
+		params = append(params, int(*spec.Status))
+	}
+	// created_at is timestamp without time zone holding UTC, postgres drops the offset of the parameter,
+	// so bounds with client offsets are converted to UTC first
+	if spec.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= ?")
+		params = append(params, spec.CreatedFrom.UTC())
+	}
+	if spec.CreatedTo != nil {
+		conditions = append(conditions, "created_at < ?")
+		params = append(params, spec.CreatedTo.UTC())
+	}
+	if spec.Currency != nil {
+		conditions = append(conditions, "currency = ?")
+		params = append(params, string(*spec.Currency))
+	}
+	if spec.MinPrice != nil {
+		conditions = append(conditions, "price >= ?")
+		params = append(params, spec.MinPrice.MinorUnits())
+	}
+	if spec.MaxPrice != nil {
+		conditions = append(conditions, "price <= ?")
+		params = append(params, spec.MaxPrice.MinorUnits())
+	}
+
+	sortColumn := "created_at"
+	if spec.SortField == app.OrderSortByPrice {
+		sortColumn = "price"
+	}
+	direction, comparison := "ASC", ">"
+	if spec.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if spec.Cursor != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (?, ?)", sortColumn, comparison))
+		if spec.SortField == app.OrderSortByPrice {
+			params = append(params, spec.Cursor.Price)
+		} else {
+			params = append(params, spec.Cursor.CreationDate.UTC())
+		}
+		params = append(params, string(spec.Cursor.ID))
+	}
+	params = append(params, spec.Limit+1)
+
+	query := fmt.Sprintf(
+		selectOrderQuery+` WHERE %s ORDER BY %s %s, id %s LIMIT ?`,
+		strings.Join(conditions, " AND "), sortColumn, direction, direction,
+	)
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
 
 	var orders []*sqlxOrder
-	err := repo.client.Select(&orders, query, string(userID))
+	err := repo.client.Select(&orders, query, params...)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	return repo.withItems(orders)
+}
+
+func (repo *orderRepository) storeItems(order *app.Order) error {
+	const query = `
+			INSERT INTO order_item (order_id, sku, name, quantity, unit_price)
+			VALUES (:order_id, :sku, :name, :quantity, :unit_price)
+			ON CONFLICT (order_id, sku) DO NOTHING
+		`
 
+	for _, item := range order.Items {
+		itemx := sqlxOrderItem{
+			OrderID:   string(order.ID),
+			SKU:       string(item.SKU),
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice.MinorUnits(),
+		}
+		_, err := repo.client.NamedExec(query, &itemx)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func (repo *orderRepository) withItems(orders []*sqlxOrder) ([]app.Order, error) {
 	res := make([]app.Order, 0, len(orders))
+	if len(orders) == 0 {
+		return res, nil
+	}
+
+	const sqlQuery = `SELECT order_id, sku, name, quantity, unit_price FROM order_item WHERE order_id IN (?) ORDER BY sku`
+
+	orderIDs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	query, params, err := sqlx.In(sqlQuery, orderIDs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	var items []*sqlxOrderItem
+	err = repo.client.Select(&items, query, params...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	itemsByOrder := make(map[string][]*sqlxOrderItem, len(orders))
+	for _, item := range items {
+		itemsByOrder[item.OrderID] = append(itemsByOrder[item.OrderID], item)
+	}
 	for _, order := range orders {
-		res = append(res, sqlxOrderToOrder(order))
+		res = append(res, sqlxOrderToOrder(order, itemsByOrder[order.ID]))
 	}
 	return res, nil
 }
 
-func sqlxOrderToOrder(order *sqlxOrder) app.Order {
+func sqlxOrderToOrder(order *sqlxOrder, items []*sqlxOrderItem) app.Order {
+	currency := money.Currency(order.Currency)
+	orderItems := make([]app.OrderItem, 0, len(items))
+	for _, item := range items {
+		orderItems = append(orderItems, sqlxOrderItemToOrderItem(item, currency))
+	}
 	return app.Order{
-		ID:           app.OrderID(order.ID),
-		UserID:       app.UserID(order.UserID),
-		Price:        app.PriceFromRawValue(order.Price),
-		CreationDate: order.CreationDate,
+		ID:            app.OrderID(order.ID),
+		UserID:        app.UserID(order.UserID),
+		Items:         orderItems,
+		Price:         money.New(order.Price, currency),
+		Discount:      money.New(order.Discount, currency),
+		PromoCode:     app.PromoCodeID(order.PromoCode),
+		Status:        app.OrderStatus(order.Status),
+		PaymentStatus: app.ConfirmationStatus(order.PaymentStatus),
+		PaymentID:     app.PaymentID(order.PaymentID),
+		StockStatus:   app.ConfirmationStatus(order.StockStatus),
+		CreationDate:  order.CreationDate.UTC(),
+	}
+}
+
+func sqlxOrderItemToOrderItem(item *sqlxOrderItem, currency money.Currency) app.OrderItem {
+	return app.OrderItem{
+		SKU:       app.SKU(item.SKU),
+		Name:      item.Name,
+		Quantity:  item.Quantity,
+		UnitPrice: money.New(item.UnitPrice, currency),
 	}
 }
 
 type sqlxOrder struct {
-	ID           string    `db:"id"`
-	UserID       string    `db:"user_id"`
-	Price        uint64    `db:"price"`
-	CreationDate time.Time `db:"created_at"`
+	ID            string    `db:"id"`
+	UserID        string    `db:"user_id"`
+	Price         int64     `db:"price"`
+	Currency      string    `db:"currency"`
+	Discount      int64     `db:"discount"`
+	PromoCode     string    `db:"promo_code"`
+	Status        int       `db:"status"`
+	PaymentStatus int       `db:"payment_status"`
+	PaymentID     string    `db:"payment_id"`
+	StockStatus   int       `db:"stock_status"`
+	CreationDate  time.Time `db:"created_at"`
+}
+
+type sqlxOrderItem struct {
+	OrderID   string `db:"order_id"`
+	SKU       string `db:"sku"`
+	Name      string `db:"name"`
+	Quantity  uint64 `db:"quantity"`
+	UnitPrice int64  `db:"unit_price"`
 }
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/infrastructure/postgres"
+	"arch-homework/pkg/order/app"
+
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+func NewProductRepository(client postgres.Client) app.ProductRepository {
+	return &productRepository{client: client}
+}
+
+type productRepository struct {
+	client postgres.Client
+}
+
+func (repo *productRepository) Store(product *app.Product) error {
+	const query = `
+			INSERT INTO product (sku, name, price, currency, active, created_at, updated_at)
+			VALUES (:sku, :name, :price, :currency, :active, :created_at, :updated_at)
+			ON CONFLICT (sku) DO UPDATE SET
+				name = excluded.name,
+				price = excluded.price,
+				currency = excluded.currency,
+				active = excluded.active,
+				updated_at = excluded.updated_at
+		`
+
+	productx := sqlxProduct{
+		SKU:          string(product.SKU),
+		Name:         product.Name,
+		Price:        product.Price.MinorUnits(),
+		Currency:     string(product.Price.Currency()),
+		Active:       product.Active,
+		CreationDate: product.CreationDate,
+		UpdateDate:   product.UpdateDate,
+	}
+
+	_, err := repo.client.NamedExec(query, &productx)
+	return errors.WithStack(err)
+}
+
+func (repo *productRepository) Remove(sku app.SKU) error {
+	const query = `DELETE FROM product WHERE sku = $1`
+
+	_, err := repo.client.Exec(query, string(sku))
+	return errors.WithStack(err)
+}
+
+func (repo *productRepository) FindBySKU(sku app.SKU) (*app.Product, error) {
+	const query = `SELECT sku, name, price, currency, active, created_at, updated_at FROM product WHERE sku = $1`
+
+	var product sqlxProduct
+	err := repo.client.Get(&product, query, string(sku))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.WithStack(app.ErrProductNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	res := sqlxProductToProduct(&product)
+	return &res, nil
+}
+
+func (repo *productRepository) FindBySKUs(skus []app.SKU) ([]app.Product, error) {
+	const sqlQuery = `SELECT sku, name, price, currency, active, created_at, updated_at FROM product WHERE sku IN (?)`
+
+	if len(skus) == 0 {
+		return nil, nil
+	}
+	skuStrings := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		skuStrings = append(skuStrings, string(sku))
+	}
+	query, params, err := sqlx.In(sqlQuery, skuStrings)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	query = sqlx.Rebind(sqlx.DOLLAR, query)
+
+	var products []*sqlxProduct
+	err = repo.client.Select(&products, query, params...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return sqlxProductsToProducts(products), nil
+}
+
+func (repo *productRepository) FindAll(activeOnly bool) ([]app.Product, error) {
+	query := `SELECT sku, name, price, currency, active, created_at, updated_at FROM product`
+	if activeOnly {
+		query += ` WHERE active`
+	}
+	query += ` ORDER BY sku`
+
+	var products []*sqlxProduct
+	err := repo.client.Select(&products, query)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return sqlxProductsToProducts(products), nil
+}
+
+func sqlxProductsToProducts(products []*sqlxProduct) []app.Product {
+	res := make([]app.Product, 0, len(products))
+	for _, product := range products {
+		res = append(res, sqlxProductToProduct(product))
+	}
+	return res
+}
+
+func sqlxProductToProduct(product *sqlxProduct) app.Product {
+	return app.Product{
+		SKU:          app.SKU(product.SKU),
+		Name:         product.Name,
+		Price:        money.New(product.Price, money.Currency(product.Currency)),
+		Active:       product.Active,
+		CreationDate: product.CreationDate,
+		UpdateDate:   product.UpdateDate,
+	}
+}
+
+type sqlxProduct struct {
+	SKU          string    `db:"sku"`
+	Name         string    `db:"name"`
+	Price        int64     `db:"price"`
+	Currency     string    `db:"currency"`
+	Active       bool      `db:"active"`
+	CreationDate time.Time `db:"created_at"`
+	UpdateDate   time.Time `db:"updated_at"`
+}
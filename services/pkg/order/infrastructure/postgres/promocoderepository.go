@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/infrastructure/postgres"
+	"arch-homework/pkg/order/app"
+
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewPromoCodeRepository(client postgres.Client) app.PromoCodeRepository {
+	return &promoCodeRepository{client: client}
+}
+
+type promoCodeRepository struct {
+	client postgres.Client
+}
+
+const selectPromoCodeQuery = `
+			SELECT code, discount_type, percent_off, amount_off, currency, min_order_total, expires_at,
+			       max_redemptions, max_redemptions_per_user, redemption_count, created_at
+			FROM promo_code WHERE code = $1
+		`
+
+func (repo *promoCodeRepository) FindByCode(code app.PromoCodeID) (*app.PromoCode, error) {
+	return repo.find(selectPromoCodeQuery, code)
+}
+
+func (repo *promoCodeRepository) FindByCodeForUpdate(code app.PromoCodeID) (*app.PromoCode, error) {
+	return repo.find(selectPromoCodeQuery+" FOR UPDATE", code)
+}
+
+func (repo *promoCodeRepository) Store(promoCode *app.PromoCode) error {
+	const query = `
+			INSERT INTO promo_code (code, discount_type, percent_off, amount_off, currency, min_order_total, expires_at,
+			                        max_redemptions, max_redemptions_per_user, redemption_count, created_at)
+			VALUES (:code, :discount_type, :percent_off, :amount_off, :currency, :min_order_total, :expires_at,
+			        :max_redemptions, :max_redemptions_per_user, :redemption_count, :created_at)
+			ON CONFLICT (code) DO UPDATE SET redemption_count = excluded.redemption_count
+		`
+
+	promoCodex := sqlxPromoCode{
+		Code:                  string(promoCode.Code),
+		DiscountType:          int(promoCode.DiscountType),
+		PercentOff:            promoCode.PercentOff,
+		AmountOff:             promoCode.AmountOff.MinorUnits(),
+		Currency:              string(promoCode.AmountOff.Currency()),
+		ExpiresAt:             promoCode.ExpiresAt,
+		MaxRedemptions:        promoCode.MaxRedemptions,
+		MaxRedemptionsPerUser: promoCode.MaxRedemptionsPerUser,
+		RedemptionCount:       promoCode.RedemptionCount,
+		CreationDate:          promoCode.CreationDate,
+	}
+	if promoCode.MinOrderTotal != nil {
+		minOrderTotal := promoCode.MinOrderTotal.MinorUnits()
+		promoCodex.MinOrderTotal = &minOrderTotal
+		promoCodex.Currency = string(promoCode.MinOrderTotal.Currency())
+	}
+
+	_, err := repo.client.NamedExec(query, &promoCodex)
+	return errors.WithStack(err)
+}
+
+func (repo *promoCodeRepository) CountUserRedemptions(code app.PromoCodeID, userID app.UserID) (uint64, error) {
+	const query = `SELECT count(*) FROM promo_code_redemption WHERE code = $1 AND user_id = $2`
+
+	var count uint64
+	err := repo.client.Get(&count, query, string(code), string(userID))
+	return count, errors.WithStack(err)
+}
+
+func (repo *promoCodeRepository) AddRedemption(redemption app.PromoCodeRedemption) error {
+	const query = `
+			INSERT INTO promo_code_redemption (order_id, code, user_id, discount)
+			VALUES ($1, $2, $3, $4)
+		`
+
+	_, err := repo.client.Exec(query,
+		string(redemption.OrderID),
+		string(redemption.Code),
+		string(redemption.UserID),
+		redemption.Discount.MinorUnits(),
+	)
+	return errors.WithStack(err)
+}
+
+func (repo *promoCodeRepository) RemoveRedemption(orderID app.OrderID) (bool, error) {
+	const query = `DELETE FROM promo_code_redemption WHERE order_id = $1`
+
+	res, err := repo.client.Exec(query, string(orderID))
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	removed, err := res.RowsAffected()
+	return removed > 0, errors.WithStack(err)
+}
+
+func (repo *promoCodeRepository) find(query string, code app.PromoCodeID) (*app.PromoCode, error) {
+	var promoCode sqlxPromoCode
+	err := repo.client.Get(&promoCode, query, string(code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.WithStack(app.ErrPromoCodeNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	res := sqlxPromoCodeToPromoCode(&promoCode)
+	return &res, nil
+}
+
+func sqlxPromoCodeToPromoCode(promoCode *sqlxPromoCode) app.PromoCode {
+	currency := money.Currency(promoCode.Currency)
+	res := app.PromoCode{
+		Code:                  app.PromoCodeID(promoCode.Code),
+		DiscountType:          app.DiscountType(promoCode.DiscountType),
+		PercentOff:            promoCode.PercentOff,
+		AmountOff:             money.New(promoCode.AmountOff, currency),
+		ExpiresAt:             promoCode.ExpiresAt,
+		MaxRedemptions:        promoCode.MaxRedemptions,
+		MaxRedemptionsPerUser: promoCode.MaxRedemptionsPerUser,
+		RedemptionCount:       promoCode.RedemptionCount,
+		CreationDate:          promoCode.CreationDate,
+	}
+	if promoCode.MinOrderTotal != nil {
+		minOrderTotal := money.New(*promoCode.MinOrderTotal, currency)
+		res.MinOrderTotal = &minOrderTotal
+	}
+	return res
+}
+
+type sqlxPromoCode struct {
+	Code                  string     `db:"code"`
+	DiscountType          int        `db:"discount_type"`
+	PercentOff            uint64     `db:"percent_off"`
+	AmountOff             int64      `db:"amount_off"`
+	Currency              string     `db:"currency"`
+	MinOrderTotal         *int64     `db:"min_order_total"`
+	ExpiresAt             *time.Time `db:"expires_at"`
+	MaxRedemptions        uint64     `db:"max_redemptions"`
+	MaxRedemptionsPerUser uint64     `db:"max_redemptions_per_user"`
+	RedemptionCount       uint64     `db:"redemption_count"`
+	CreationDate          time.Time  `db:"created_at"`
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"arch-homework/pkg/order/app"
+
+	"net/http"
+	"time"
+)
+
+func (s *Server) getOrderHistoryHandler(w http.ResponseWriter, r *http.Request) error {
+	tokenData, err := s.extractAuthorizationData(r)
+	if err != nil {
+		return err
+	}
+	orderID, err := getIDFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	records, err := s.orderService.History(app.UserID(tokenData.UserID()), orderID)
+	if err != nil {
+		return err
+	}
+	infos := make([]orderHistoryRecordInfo, 0, len(records))
+	for _, record := range records {
+		infos = append(infos, orderHistoryRecordInfo{
+			Type:      string(record.Type),
+			Actor:     string(record.Actor),
+			Details:   record.Details,
+			Timestamp: record.CreationDate.Format(time.RFC3339Nano),
+		})
+	}
+	writeResponse(w, orderHistoryResponse{History: infos})
+	return nil
+}
+
+type orderHistoryResponse struct {
+	History []orderHistoryRecordInfo `json:"history"`
+}
+
+type orderHistoryRecordInfo struct {
+	Type      string `json:"type"`
+	Actor     string `json:"actor"`
+	Details   string `json:"details,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
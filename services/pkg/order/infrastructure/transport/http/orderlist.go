@@ -0,0 +1,186 @@
+package http
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/common/app/uuid"
+	"arch-homework/pkg/order/app"
+
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	listParamLimit       = "limit"
+	listParamCursor      = "cursor"
+	listParamStatus      = "status"
+	listParamCreatedFrom = "createdFrom"
+	listParamCreatedTo   = "createdTo"
+	listParamMinPrice    = "minPrice"
+	listParamMaxPrice    = "maxPrice"
+	listParamCurrency    = "currency"
+	listParamSort        = "sort"
+	listParamOrder       = "order"
+)
+
+const (
+	sortByDate  = "date"
+	sortByPrice = "price"
+	orderAsc    = "asc"
+	orderDesc   = "desc"
+)
+
+var errInvalidListParam = errors.New("invalid order list parameter")
+
+func parseOrderListSpec(r *http.Request, userID app.UserID) (app.OrderListSpec, error) {
+	query := r.URL.Query()
+	spec := app.OrderListSpec{
+		UserID:     userID,
+		SortField:  app.OrderSortByCreationDate,
+		Descending: true,
+		Limit:      app.DefaultOrderPageSize,
+	}
+
+	if value := query.Get(listParamLimit); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return spec, errors.Wrap(errInvalidListParam, listParamLimit)
+		}
+		spec.Limit = limit
+	}
+	if value := query.Get(listParamStatus); value != "" {
+		status, err := orderStatusFromString(value)
+		if err != nil {
+			return spec, errors.Wrap(errInvalidListParam, listParamStatus)
+		}
+		spec.Status = &status
+	}
+	var err error
+	if spec.CreatedFrom, err = parseTimeParam(query.Get(listParamCreatedFrom)); err != nil {
+		return spec, errors.Wrap(errInvalidListParam, listParamCreatedFrom)
+	}
+	if spec.CreatedTo, err = parseTimeParam(query.Get(listParamCreatedTo)); err != nil {
+		return spec, errors.Wrap(errInvalidListParam, listParamCreatedTo)
+	}
+	if value := query.Get(listParamCurrency); value != "" {
+		currency, err := money.ParseCurrency(value)
+		if err != nil {
+			return spec, errors.Wrap(errInvalidListParam, listParamCurrency)
+		}
+		spec.Currency = &currency
+	}
+	if spec.MinPrice, err = parsePriceParam(query.Get(listParamMinPrice), spec.Currency); err != nil {
+		return spec, errors.Wrap(errInvalidListParam, listParamMinPrice)
+	}
+	if spec.MaxPrice, err = parsePriceParam(query.Get(listParamMaxPrice), spec.Currency); err != nil {
+		return spec, errors.Wrap(errInvalidListParam, listParamMaxPrice)
+	}
+
+	switch query.Get(listParamSort) {
+	case "", sortByDate:
+		spec.SortField = app.OrderSortByCreationDate
+	case sortByPrice:
+		spec.SortField = app.OrderSortByPrice
+	default:
+		return spec, errors.Wrap(errInvalidListParam, listParamSort)
+	}
+	switch query.Get(listParamOrder) {
+	case "", orderDesc:
+		spec.Descending = true
+	case orderAsc:
+		spec.Descending = false
+	default:
+		return spec, errors.Wrap(errInvalidListParam, listParamOrder)
+	}
+
+	if value := query.Get(listParamCursor); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			return spec, errors.Wrap(errInvalidListParam, listParamCursor)
+		}
+		spec.Cursor = cursor
+	}
+	return spec, nil
+}
+
+func parseTimeParam(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parsePriceParam parses decimal price in currency passed with currency param
+func parsePriceParam(value string, currency *money.Currency) (*money.Money, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if currency == nil {
+		return nil, errors.New("price filter requires currency")
+	}
+	price, err := money.Parse(value, *currency)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+func encodeCursor(cursor *app.OrderCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	data, _ := json.Marshal(cursorView{
+		SortField:    int(cursor.SortField),
+		Descending:   cursor.Descending,
+		CreationDate: cursor.CreationDate.Format(time.RFC3339Nano),
+		Price:        cursor.Price,
+		ID:           string(cursor.ID),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*app.OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var view cursorView
+	if err = json.Unmarshal(data, &view); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = uuid.ValidateUUID(view.ID); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	creationDate, err := time.Parse(time.RFC3339Nano, view.CreationDate)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &app.OrderCursor{
+		SortField:    app.OrderSortField(view.SortField),
+		Descending:   view.Descending,
+		CreationDate: creationDate,
+		Price:        view.Price,
+		ID:           app.OrderID(view.ID),
+	}, nil
+}
+
+type cursorView struct {
+	SortField    int    `json:"s"`
+	Descending   bool   `json:"d"`
+	CreationDate string `json:"c"`
+	Price        int64  `json:"p"`
+	ID           string `json:"id"`
+}
+
+type orderListResponse struct {
+	Orders     []orderInfo `json:"orders"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
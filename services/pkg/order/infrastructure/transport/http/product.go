@@ -0,0 +1,110 @@
+package http
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/order/app"
+
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func (s *Server) getActiveProductsHandler(w http.ResponseWriter, _ *http.Request) error {
+	return s.writeProducts(w, true)
+}
+
+func (s *Server) getAllProductsHandler(w http.ResponseWriter, _ *http.Request) error {
+	return s.writeProducts(w, false)
+}
+
+func (s *Server) getProductHandler(w http.ResponseWriter, r *http.Request) error {
+	product, err := s.catalogService.Get(getSKUFromRequest(r))
+	if err != nil {
+		return err
+	}
+	writeResponse(w, toProductInfo(product))
+	return nil
+}
+
+func (s *Server) createProductHandler(w http.ResponseWriter, r *http.Request) error {
+	// New products are available for ordering unless explicitly disabled
+	info := productInfo{Active: true}
+	if err := readJSONBody(r, &info); err != nil {
+		return err
+	}
+	if err := s.catalogService.Create(app.SKU(info.SKU), info.Name, info.Price, info.Active); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) updateProductHandler(w http.ResponseWriter, r *http.Request) error {
+	var info productInfo
+	if err := readJSONBody(r, &info); err != nil {
+		return err
+	}
+	if err := s.catalogService.Update(getSKUFromRequest(r), info.Name, info.Price, info.Active); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) removeProductHandler(w http.ResponseWriter, r *http.Request) error {
+	if err := s.catalogService.Remove(getSKUFromRequest(r)); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) writeProducts(w http.ResponseWriter, activeOnly bool) error {
+	products, err := s.catalogService.List(activeOnly)
+	if err != nil {
+		return err
+	}
+	infos := make([]productInfo, 0, len(products))
+	for i := range products {
+		infos = append(infos, toProductInfo(&products[i]))
+	}
+	writeResponse(w, infos)
+	return nil
+}
+
+func getSKUFromRequest(r *http.Request) app.SKU {
+	return app.SKU(mux.Vars(r)["sku"])
+}
+
+func readJSONBody(r *http.Request, v interface{}) error {
+	bytesBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_ = r.Body.Close()
+	return json.Unmarshal(bytesBody, v)
+}
+
+func toProductInfo(product *app.Product) productInfo {
+	return productInfo{
+		SKU:          string(product.SKU),
+		Name:         product.Name,
+		Price:        product.Price,
+		Active:       product.Active,
+		CreationDate: product.CreationDate.Format(time.RFC3339),
+		UpdateDate:   product.UpdateDate.Format(time.RFC3339),
+	}
+}
+
+type productInfo struct {
+	SKU          string      `json:"sku"`
+	Name         string      `json:"name"`
+	Price        money.Money `json:"price"`
+	Active       bool        `json:"active"`
+	CreationDate string      `json:"creationDate,omitempty"`
+	UpdateDate   string      `json:"updateDate,omitempty"`
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"arch-homework/pkg/common/app/money"
+	"arch-homework/pkg/order/app"
+
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	discountTypePercentage = "percentage"
+	discountTypeFixed      = "fixed"
+)
+
+func (s *Server) createPromoCodeHandler(w http.ResponseWriter, r *http.Request) error {
+	var info promoCodeInfo
+	bytesBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	_ = r.Body.Close()
+	if err = json.Unmarshal(bytesBody, &info); err != nil {
+		return err
+	}
+
+	promoCode, err := toPromoCode(info)
+	if err != nil {
+		return err
+	}
+	if err = s.promoCodeService.Create(promoCode); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) getPromoCodeHandler(w http.ResponseWriter, r *http.Request) error {
+	code, err := app.NormalizePromoCode(mux.Vars(r)["code"])
+	if err != nil {
+		return err
+	}
+	promoCode, err := s.promoCodeService.Get(code)
+	if err != nil {
+		return err
+	}
+	info, err := toPromoCodeInfo(promoCode)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, info)
+	return nil
+}
+
+// parseOptionalPromoCode returns empty code when order is created without promo code
+func parseOptionalPromoCode(code string) (app.PromoCodeID, error) {
+	if code == "" {
+		return "", nil
+	}
+	return app.NormalizePromoCode(code)
+}
+
+func toPromoCode(info promoCodeInfo) (app.PromoCode, error) {
+	code, err := app.NormalizePromoCode(info.Code)
+	if err != nil {
+		return app.PromoCode{}, err
+	}
+	promoCode := app.PromoCode{
+		Code:                  code,
+		PercentOff:            info.PercentOff,
+		MinOrderTotal:         info.MinOrderTotal,
+		MaxRedemptions:        info.MaxRedemptions,
+		MaxRedemptionsPerUser: info.MaxRedemptionsPerUser,
+	}
+	switch info.DiscountType {
+	case discountTypePercentage:
+		promoCode.DiscountType = app.DiscountTypePercentage
+	case discountTypeFixed:
+		promoCode.DiscountType = app.DiscountTypeFixed
+		if info.AmountOff == nil {
+			return app.PromoCode{}, errors.Wrap(app.ErrInvalidPromoCode, "amount off required")
+		}
+		promoCode.AmountOff = *info.AmountOff
+	default:
+		return app.PromoCode{}, errors.Wrap(app.ErrInvalidPromoCode, "unknown discount type")
+	}
+	if info.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, info.ExpiresAt)
+		if err != nil {
+			return app.PromoCode{}, errors.Wrap(app.ErrInvalidPromoCode, "expiration date")
+		}
+		promoCode.ExpiresAt = &expiresAt
+	}
+	return promoCode, nil
+}
+
+func toPromoCodeInfo(promoCode *app.PromoCode) (promoCodeInfo, error) {
+	info := promoCodeInfo{
+		Code:                  string(promoCode.Code),
+		MinOrderTotal:         promoCode.MinOrderTotal,
+		MaxRedemptions:        promoCode.MaxRedemptions,
+		MaxRedemptionsPerUser: promoCode.MaxRedemptionsPerUser,
+		RedemptionCount:       promoCode.RedemptionCount,
+	}
+	switch promoCode.DiscountType {
+	case app.DiscountTypePercentage:
+		info.DiscountType = discountTypePercentage
+		info.PercentOff = promoCode.PercentOff
+	case app.DiscountTypeFixed:
+		info.DiscountType = discountTypeFixed
+		amountOff := promoCode.AmountOff
+		info.AmountOff = &amountOff
+	default:
+		return info, errors.New("unknown discount type")
+	}
+	if promoCode.ExpiresAt != nil {
+		info.ExpiresAt = promoCode.ExpiresAt.Format(time.RFC3339)
+	}
+	return info, nil
+}
+
+type promoCodeInfo struct {
+	Code                  string       `json:"code"`
+	DiscountType          string       `json:"discountType"`
+	PercentOff            uint64       `json:"percentOff,omitempty"`
+	AmountOff             *money.Money `json:"amountOff,omitempty"`
+	MinOrderTotal         *money.Money `json:"minOrderTotal,omitempty"`
+	ExpiresAt             string       `json:"expiresAt,omitempty"`
+	MaxRedemptions        uint64       `json:"maxRedemptions"`
+	MaxRedemptionsPerUser uint64       `json:"maxRedemptionsPerUser"`
+	RedemptionCount       uint64       `json:"redemptionCount"`
+}
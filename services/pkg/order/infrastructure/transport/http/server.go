@@ -1,6 +1,7 @@
 package http
 
 import (
+	"arch-homework/pkg/common/app/money"
 	"arch-homework/pkg/common/app/uuid"
 	"arch-homework/pkg/common/infrastructure/metrics"
 	"arch-homework/pkg/common/jwtauth"
@@ -21,11 +22,21 @@ import (
 )
 
 const PathPrefix = "/api/v1/"
+const PathPrefixInternal = "/internal/api/v1/"
 
 const (
-	createOrderEndpoint  = PathPrefix + "order"
-	ordersEndpoint       = PathPrefix + "orders"
-	specificOderEndpoint = PathPrefix + "order/{id}"
+	createOrderEndpoint   = PathPrefix + "order"
+	ordersEndpoint        = PathPrefix + "orders"
+	specificOderEndpoint  = PathPrefix + "order/{id}"
+	cancelOrderEndpoint   = PathPrefix + "order/{id}/cancel"
+	orderHistoryEndpoint  = PathPrefix + "order/{id}/history"
+	productsEndpoint      = PathPrefix + "products"
+	completeOrderEndpoint = PathPrefixInternal + "order/{id}/complete"
+	promoCodesEndpoint    = PathPrefixInternal + "promocode"
+	promoCodeEndpoint     = PathPrefixInternal + "promocode/{code}"
+	adminProductsEndpoint = PathPrefixInternal + "products"
+	createProductEndpoint = PathPrefixInternal + "product"
+	adminProductEndpoint  = PathPrefixInternal + "product/{sku}"
 )
 
 const (
@@ -33,7 +44,28 @@ const (
 	errorCodeInvalidRequestID = 1
 	errorCodeAlreadyProcessed = 2
 	errorCodeOrderNotFound    = 3
-	errorCodePaymentFailed    = 4
+	errorCodeInvalidMoney     = 4
+	errorCodeInvalidStatus    = 5
+	errorCodeInvalidItems     = 6
+	errorCodeInvalidListParam = 7
+	errorCodeCurrencyMismatch = 8
+	errorCodePromoCodeInvalid = 9
+	errorCodePromoCodeExists  = 10
+	errorCodePromoCodeExpired = 11
+	errorCodePromoCodeMinimum = 12
+	errorCodePromoCodeLimit   = 13
+	errorCodeProductNotFound  = 14
+	errorCodeProductInactive  = 15
+	errorCodeProductExists    = 16
+	errorCodeInvalidProduct   = 17
+)
+
+const (
+	orderStatusPending   = "pending"
+	orderStatusPaid      = "paid"
+	orderStatusRejected  = "rejected"
+	orderStatusCancelled = "cancelled"
+	orderStatusCompleted = "completed"
 )
 
 const authTokenHeader = "X-Auth-Token"
@@ -55,22 +87,54 @@ func (e endpointLabelCollector) EndpointLabelForURI(uri string) string {
 		if r.MatchString(uri) {
 			return specificOderEndpoint
 		}
+		r, _ = regexp.Compile("^" + PathPrefix + "order/[a-f0-9-]+/cancel$")
+		if r.MatchString(uri) {
+			return cancelOrderEndpoint
+		}
+		r, _ = regexp.Compile("^" + PathPrefix + "order/[a-f0-9-]+/history$")
+		if r.MatchString(uri) {
+			return orderHistoryEndpoint
+		}
+	}
+	if strings.HasPrefix(uri, PathPrefixInternal) {
+		r, _ := regexp.Compile("^" + PathPrefixInternal + "order/[a-f0-9-]+/complete$")
+		if r.MatchString(uri) {
+			return completeOrderEndpoint
+		}
+		r, _ = regexp.Compile("^" + PathPrefixInternal + "promocode/[^/]+$")
+		if r.MatchString(uri) {
+			return promoCodeEndpoint
+		}
+		r, _ = regexp.Compile("^" + PathPrefixInternal + "product/[^/]+$")
+		if r.MatchString(uri) {
+			return adminProductEndpoint
+		}
 	}
 	return uri
 }
 
-func NewServer(orderService *app.OrderService, tokenParser jwtauth.TokenParser, logger *logrus.Logger) *Server {
+func NewServer(
+	orderService *app.OrderService,
+	promoCodeService *app.PromoCodeService,
+	catalogService *app.CatalogService,
+	tokenParser jwtauth.TokenParser,
+	logger *logrus.Logger,
+) *Server {
 	return &Server{
-		orderService: orderService,
-		tokenParser:  tokenParser,
-		logger:       logger,
+		orderService:     orderService,
+		promoCodeService: promoCodeService,
+		catalogService:   catalogService,
+		tokenParser:      tokenParser,
+		logger:           logger,
 	}
 }
 
 type Server struct {
-	orderService *app.OrderService
-	tokenParser  jwtauth.TokenParser
-	logger       *logrus.Logger
+	orderService     *app.OrderService
+	promoCodeService *app.PromoCodeService
+	catalogService   *app.CatalogService
+	tokenParser      jwtauth.TokenParser
+	logger           *logrus.Logger
 }
 
 func (s *Server) MakeHandler() http.Handler {
@@ -78,8 +142,24 @@ func (s *Server) MakeHandler() http.Handler {
 
 	router.Methods(http.MethodPost).Path(createOrderEndpoint).Handler(s.makeHandlerFunc(s.createOrderHandler))
 	router.Methods(http.MethodGet).Path(specificOderEndpoint).Handler(s.makeHandlerFunc(s.getOrderHandler))
+	router.Methods(http.MethodPost).Path(cancelOrderEndpoint).Handler(s.makeHandlerFunc(s.cancelOrderHandler))
+	router.Methods(http.MethodGet).Path(orderHistoryEndpoint).Handler(s.makeHandlerFunc(s.getOrderHistoryHandler))
 	router.Methods(http.MethodGet).Path(ordersEndpoint).Handler(s.makeHandlerFunc(s.getOrdersHandler))
+	router.Methods(http.MethodGet).Path(productsEndpoint).Handler(s.makeHandlerFunc(s.getActiveProductsHandler))
+
+	return router
+}
 
+func (s *Server) MakeInternalHandler() http.Handler {
+	router := mux.NewRouter()
+	router.Methods(http.MethodPost).Path(completeOrderEndpoint).Handler(s.makeHandlerFunc(s.completeOrderHandler))
+	router.Methods(http.MethodPost).Path(promoCodesEndpoint).Handler(s.makeHandlerFunc(s.createPromoCodeHandler))
+	router.Methods(http.MethodGet).Path(promoCodeEndpoint).Handler(s.makeHandlerFunc(s.getPromoCodeHandler))
+	router.Methods(http.MethodGet).Path(adminProductsEndpoint).Handler(s.makeHandlerFunc(s.getAllProductsHandler))
+	router.Methods(http.MethodPost).Path(createProductEndpoint).Handler(s.makeHandlerFunc(s.createProductHandler))
+	router.Methods(http.MethodGet).Path(adminProductEndpoint).Handler(s.makeHandlerFunc(s.getProductHandler))
+	router.Methods(http.MethodPut).Path(adminProductEndpoint).Handler(s.makeHandlerFunc(s.updateProductHandler))
+	router.Methods(http.MethodDelete).Path(adminProductEndpoint).Handler(s.makeHandlerFunc(s.removeProductHandler))
 	return router
 }
 
@@ -136,7 +216,11 @@ func (s *Server) getOrderHandler(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	writeResponse(w, toOrderInfo(*order))
+	info, err := toOrderInfo(*order)
+	if err != nil {
+		return err
+	}
+	writeResponse(w, info)
 	return nil
 }
 
@@ -146,15 +230,26 @@ func (s *Server) getOrdersHandler(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
-	orders, err := s.orderService.FindAll(app.UserID(tokenData.UserID()))
+	spec, err := parseOrderListSpec(r, app.UserID(tokenData.UserID()))
 	if err != nil {
 		return err
 	}
-	orderInfos := make([]orderInfo, 0, len(orders))
-	for _, order := range orders {
-		orderInfos = append(orderInfos, toOrderInfo(order))
+	page, err := s.orderService.FindList(spec)
+	if err != nil {
+		return err
 	}
-	writeResponse(w, orderInfos)
+	orderInfos := make([]orderInfo, 0, len(page.Orders))
+	for _, order := range page.Orders {
+		info, err := toOrderInfo(order)
+		if err != nil {
+			return err
+		}
+		orderInfos = append(orderInfos, info)
+	}
+	writeResponse(w, orderListResponse{
+		Orders:     orderInfos,
+		NextCursor: encodeCursor(page.NextCursor),
+	})
 	return nil
 }
 
@@ -164,8 +259,7 @@ func (s *Server) createOrderHandler(w http.ResponseWriter, r *http.Request) erro
 		return err
 	}
 
-	requestID, err := s.getRequestIDHeader(r)
-	if err != nil {
+	if err = checkRequestIDHeader(r); err != nil {
 		return err
 	}
 
@@ -179,7 +273,12 @@ func (s *Server) createOrderHandler(w http.ResponseWriter, r *http.Request) erro
 		return err
 	}
 
-	orderID, err := s.orderService.Create(requestID, app.UserID(tokenData.UserID()), info.Price)
+	promoCode, err := parseOptionalPromoCode(info.PromoCode)
+	if err != nil {
+		return err
+	}
+
+	orderID, err := s.orderService.Create(app.UserID(tokenData.UserID()), toOrderLines(info.Items), promoCode)
 	if err != nil {
 		return err
 	}
@@ -188,6 +287,39 @@ func (s *Server) createOrderHandler(w http.ResponseWriter, r *http.Request) erro
 	return nil
 }
 
+func (s *Server) cancelOrderHandler(w http.ResponseWriter, r *http.Request) error {
+	tokenData, err := s.extractAuthorizationData(r)
+	if err != nil {
+		return err
+	}
+	orderID, err := getIDFromRequest(r)
+	if err != nil {
+		return err
+	}
+	if err = checkRequestIDHeader(r); err != nil {
+		return err
+	}
+
+	if err = s.orderService.Cancel(app.UserID(tokenData.UserID()), orderID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (s *Server) completeOrderHandler(w http.ResponseWriter, r *http.Request) error {
+	orderID, err := getIDFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if err = s.orderService.Complete(orderID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
 func (s *Server) extractAuthorizationData(r *http.Request) (jwtauth.TokenData, error) {
 	token := r.Header.Get(authTokenHeader)
 	if token == "" {
@@ -203,13 +335,13 @@ func (s *Server) extractAuthorizationData(r *http.Request) (jwtauth.TokenData, e
 	return tokenData, nil
 }
 
-func (s *Server) getRequestIDHeader(r *http.Request) (app.RequestID, error) {
-	requestID := r.Header.Get(requestIDHeader)
-	err := uuid.ValidateUUID(requestID)
+// checkRequestIDHeader requires the request id, so retries of the request are always replayed by the idempotency middleware
+func checkRequestIDHeader(r *http.Request) error {
+	err := uuid.ValidateUUID(r.Header.Get(requestIDHeader))
 	if err != nil {
-		return "", errors.Wrap(errInvalidRequestID, err.Error())
+		return errors.Wrap(errInvalidRequestID, err.Error())
 	}
-	return app.RequestID(requestID), nil
+	return nil
 }
 
 func getIDFromRequest(r *http.Request) (app.OrderID, error) {
@@ -241,15 +373,54 @@ func writeErrorResponse(w http.ResponseWriter, err error) {
 	case errInvalidRequestID:
 		info.Code = errorCodeInvalidRequestID
 		w.WriteHeader(http.StatusBadRequest)
-	case app.ErrAlreadyProcessed:
-		info.Code = errorCodeAlreadyProcessed
-		w.WriteHeader(http.StatusConflict)
 	case app.ErrOrderNotFound:
 		info.Code = errorCodeOrderNotFound
 		w.WriteHeader(http.StatusNotFound)
-	case app.ErrPaymentFailed:
-		info.Code = errorCodePaymentFailed
+	case app.ErrEmptyOrder, app.ErrInvalidSKU, app.ErrDuplicateSKU, app.ErrInvalidQuantity:
+		info.Code = errorCodeInvalidItems
+		w.WriteHeader(http.StatusBadRequest)
+	case money.ErrInvalidAmount, money.ErrUnknownCurrency, money.ErrOverflow:
+		info.Code = errorCodeInvalidMoney
+		w.WriteHeader(http.StatusBadRequest)
+	case money.ErrCurrencyMismatch:
+		info.Code = errorCodeCurrencyMismatch
 		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrProductNotFound:
+		info.Code = errorCodeProductNotFound
+		w.WriteHeader(http.StatusNotFound)
+	case app.ErrProductInactive:
+		info.Code = errorCodeProductInactive
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case app.ErrProductAlreadyExists:
+		info.Code = errorCodeProductExists
+		w.WriteHeader(http.StatusConflict)
+	case app.ErrInvalidProduct:
+		info.Code = errorCodeInvalidProduct
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrInvalidPromoCode:
+		info.Code = errorCodePromoCodeInvalid
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrPromoCodeNotFound:
+		info.Code = errorCodePromoCodeInvalid
+		w.WriteHeader(http.StatusNotFound)
+	case app.ErrPromoCodeAlreadyExists:
+		info.Code = errorCodePromoCodeExists
+		w.WriteHeader(http.StatusConflict)
+	case app.ErrPromoCodeExpired:
+		info.Code = errorCodePromoCodeExpired
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case app.ErrPromoCodeMinOrderTotal:
+		info.Code = errorCodePromoCodeMinimum
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case app.ErrPromoCodeUsageLimit:
+		info.Code = errorCodePromoCodeLimit
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case errInvalidListParam, app.ErrInvalidListSpec:
+		info.Code = errorCodeInvalidListParam
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrInvalidStatusTransition:
+		info.Code = errorCodeInvalidStatus
+		w.WriteHeader(http.StatusConflict)
 	case errForbidden:
 		w.WriteHeader(http.StatusForbidden)
 	default:
@@ -259,11 +430,79 @@ func writeErrorResponse(w http.ResponseWriter, err error) {
 	_, _ = w.Write(js)
 }
 
-func toOrderInfo(order app.Order) orderInfo {
+func toOrderInfo(order app.Order) (orderInfo, error) {
+	status, err := orderStatusToString(order.Status)
+	if err != nil {
+		return orderInfo{}, errors.WithStack(err)
+	}
+	items := make([]orderItemInfo, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, orderItemInfo{
+			SKU:       string(item.SKU),
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+		})
+	}
+	subtotal, err := order.Subtotal()
+	if err != nil {
+		return orderInfo{}, err
+	}
 	return orderInfo{
 		ID:           string(order.ID),
-		Price:        order.Price.Value(),
+		Items:        items,
+		Subtotal:     subtotal,
+		Discount:     order.Discount,
+		PromoCode:    string(order.PromoCode),
+		Price:        order.Price,
+		Status:       status,
+		PaymentID:    string(order.PaymentID),
 		CreationDate: order.CreationDate.Format(time.RFC3339),
+	}, nil
+}
+
+func orderStatusFromString(status string) (app.OrderStatus, error) {
+	switch status {
+	case orderStatusPending:
+		return app.OrderStatusPending, nil
+	case orderStatusPaid:
+		return app.OrderStatusPaid, nil
+	case orderStatusRejected:
+		return app.OrderStatusRejected, nil
+	case orderStatusCancelled:
+		return app.OrderStatusCancelled, nil
+	case orderStatusCompleted:
+		return app.OrderStatusCompleted, nil
+	default:
+		return 0, errors.New("unknown order status")
+	}
+}
+
+func toOrderLines(infos []orderLineInfo) []app.OrderLine {
+	lines := make([]app.OrderLine, 0, len(infos))
+	for _, info := range infos {
+		lines = append(lines, app.OrderLine{
+			SKU:      app.SKU(info.SKU),
+			Quantity: info.Quantity,
+		})
+	}
+	return lines
+}
+
+func orderStatusToString(status app.OrderStatus) (string, error) {
+	switch status {
+	case app.OrderStatusPending:
+		return orderStatusPending, nil
+	case app.OrderStatusPaid:
+		return orderStatusPaid, nil
+	case app.OrderStatusRejected:
+		return orderStatusRejected, nil
+	case app.OrderStatusCancelled:
+		return orderStatusCancelled, nil
+	case app.OrderStatusCompleted:
+		return orderStatusCompleted, nil
+	default:
+		return "", errors.New("unknown order status")
 	}
 }
 
@@ -273,13 +512,32 @@ type errorInfo struct {
 }
 
 type orderInfo struct {
-	ID           string  `json:"id"`
-	Price        float64 `json:"price"`
-	CreationDate string  `json:"creationDate"`
+	ID           string          `json:"id"`
+	Items        []orderItemInfo `json:"items"`
+	Subtotal     money.Money     `json:"subtotal"`
+	Discount     money.Money     `json:"discount"`
+	PromoCode    string          `json:"promoCode,omitempty"`
+	Price        money.Money     `json:"price"`
+	Status       string          `json:"status"`
+	PaymentID    string          `json:"paymentId,omitempty"`
+	CreationDate string          `json:"creationDate"`
+}
+
+type orderItemInfo struct {
+	SKU       string      `json:"sku"`
+	Name      string      `json:"name"`
+	Quantity  uint64      `json:"quantity"`
+	UnitPrice money.Money `json:"unitPrice"`
 }
 
 type createOrderInfo struct {
-	Price float64 `json:"price"`
+	Items     []orderLineInfo `json:"items"`
+	PromoCode string          `json:"promoCode"`
+}
+
+type orderLineInfo struct {
+	SKU      string `json:"sku"`
+	Quantity uint64 `json:"quantity"`
 }
 
 type createOrderResponse struct {
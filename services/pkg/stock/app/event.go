@@ -0,0 +1,111 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/uuid"
+
+	"encoding/json"
+)
+
+const typeStockReserved = "stock.stock_reserved"
+const typeStockReservationFailed = "stock.stock_reservation_failed"
+const typeStockReleased = "stock.stock_released"
+
+type ReservationFailureReason string
+
+const (
+	ReservationFailureOutOfStock ReservationFailureReason = "out_of_stock"
+)
+
+type ProcessedEventRepository interface {
+	SetEventProcessed(uid integrationevent.EventUID) (alreadyProcessed bool, err error)
+}
+
+type OrderEvent interface {
+	OrderID() OrderID
+}
+
+func NewOrderCreatedEvent(orderID OrderID, items []ReservationItem) OrderEvent {
+	return orderCreatedEvent{orderID: orderID, items: items}
+}
+
+type orderCreatedEvent struct {
+	orderID OrderID
+	items   []ReservationItem
+}
+
+func (e orderCreatedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+func NewOrderRejectedEvent(orderID OrderID) OrderEvent {
+	return orderRejectedEvent{orderID: orderID}
+}
+
+type orderRejectedEvent struct {
+	orderID OrderID
+}
+
+func (e orderRejectedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+func NewOrderCancelledEvent(orderID OrderID) OrderEvent {
+	return orderCancelledEvent{orderID: orderID}
+}
+
+type orderCancelledEvent struct {
+	orderID OrderID
+}
+
+func (e orderCancelledEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+func NewOrderCompletedEvent(orderID OrderID) OrderEvent {
+	return orderCompletedEvent{orderID: orderID}
+}
+
+type orderCompletedEvent struct {
+	orderID OrderID
+}
+
+func (e orderCompletedEvent) OrderID() OrderID {
+	return e.orderID
+}
+
+func NewStockReservedEvent(orderID OrderID) integrationevent.EventData {
+	return newStockEvent(typeStockReserved, stockEventBody{OrderID: string(orderID)})
+}
+
+func NewStockReservationFailedEvent(orderID OrderID, reason ReservationFailureReason, sku SKU) integrationevent.EventData {
+	return newStockEvent(typeStockReservationFailed, stockEventBody{
+		OrderID: string(orderID),
+		Reason:  string(reason),
+		SKU:     string(sku),
+	})
+}
+
+func NewStockReleasedEvent(orderID OrderID) integrationevent.EventData {
+	return newStockEvent(typeStockReleased, stockEventBody{OrderID: string(orderID)})
+}
+
+func newStockEvent(eventType string, body stockEventBody) integrationevent.EventData {
+	bytesBody, _ := json.Marshal(body)
+
+	return integrationevent.EventData{
+		UID:  newUID(),
+		Type: eventType,
+		Body: string(bytesBody),
+	}
+}
+
+func newUID() integrationevent.EventUID {
+	return integrationevent.EventUID(uuid.GenerateNew())
+}
+
+type stockEventBody struct {
+	OrderID string `json:"order_id"`
+	Reason  string `json:"reason,omitempty"`
+	SKU     string `json:"sku,omitempty"`
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/storedevent"
+)
+
+type IntegrationEventParser interface {
+	ParseIntegrationEvent(event integrationevent.EventData) (OrderEvent, error)
+}
+
+func NewEventHandler(
+	trUnitFactory TransactionalUnitFactory,
+	eventSender storedevent.Sender,
+	parser IntegrationEventParser,
+) integrationevent.EventHandler {
+	return &eventHandler{
+		trUnitFactory: trUnitFactory,
+		eventSender:   eventSender,
+		parser:        parser,
+	}
+}
+
+type eventHandler struct {
+	trUnitFactory TransactionalUnitFactory
+	eventSender   storedevent.Sender
+	parser        IntegrationEventParser
+}
+
+func (handler *eventHandler) Handle(event integrationevent.EventData) error {
+	parsedEvent, err := handler.parser.ParseIntegrationEvent(event)
+	if err != nil || parsedEvent == nil {
+		return err
+	}
+
+	err = handler.executeInTransaction(func(trUnit TransactionalUnit) error {
+		eventRepo := trUnit.ProcessedEventRepository()
+		alreadyProcessed, err := eventRepo.SetEventProcessed(event.UID)
+		if err != nil {
+			return err
+		}
+		if alreadyProcessed {
+			return nil
+		}
+
+		service := NewStockService(trUnit, nestedEventSender{Sender: handler.eventSender})
+		switch e := parsedEvent.(type) {
+		case orderCreatedEvent:
+			return service.ReserveOrder(e.OrderID(), e.items)
+		case orderRejectedEvent:
+			return service.ReleaseOrder(e.OrderID())
+		case orderCancelledEvent:
+			return service.ReleaseOrder(e.OrderID())
+		case orderCompletedEvent:
+			return service.CommitOrder(e.OrderID())
+		default:
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	handler.eventSender.SendStoredEvents()
+	return nil
+}
+
+func (handler *eventHandler) executeInTransaction(f func(TransactionalUnit) error) (err error) {
+	var trUnit TransactionalUnit
+	trUnit, err = handler.trUnitFactory.NewTransactionalUnit()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = trUnit.Complete(err)
+	}()
+	err = f(trUnit)
+	return err
+}
+
+// nestedEventSender postpones sending until the outer transaction is committed
+type nestedEventSender struct {
+	storedevent.Sender
+}
+
+func (s nestedEventSender) SendStoredEvents() {
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/uuid"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrReservationNotFound = errors.New("reservation not found")
+
+type OrderID uuid.UUID
+
+type ReservationStatus int
+
+const (
+	ReservationStatusReserved  ReservationStatus = 0
+	ReservationStatusReleased  ReservationStatus = 1
+	ReservationStatusCommitted ReservationStatus = 2
+)
+
+// Reservation holds stock reserved by the order until it is cancelled, rejected or completed
+type Reservation struct {
+	OrderID      OrderID
+	Items        []ReservationItem
+	Status       ReservationStatus
+	CreationDate time.Time
+	UpdateDate   time.Time
+}
+
+type ReservationItem struct {
+	SKU      SKU
+	Quantity uint64
+}
+
+type ReservationRepository interface {
+	FindByOrderIDForUpdate(orderID OrderID) (*Reservation, error)
+	Store(reservation *Reservation) error
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"github.com/pkg/errors"
+)
+
+var ErrStockItemNotFound = errors.New("stock item not found")
+var ErrInvalidSKU = errors.New("sku should not be empty")
+var ErrNotEnoughStock = errors.New("not enough items in stock")
+
+const maxSKULen = 64
+
+type SKU string
+
+// StockItem keeps quantity available for new orders and quantity reserved by pending and paid orders
+type StockItem struct {
+	SKU       SKU
+	Available uint64
+	Reserved  uint64
+}
+
+func (item *StockItem) Reserve(quantity uint64) error {
+	if item.Available < quantity {
+		return errors.Wrapf(ErrNotEnoughStock, "sku '%s'", item.SKU)
+	}
+	item.Available -= quantity
+	item.Reserved += quantity
+	return nil
+}
+
+// Release returns reserved quantity back to available stock
+func (item *StockItem) Release(quantity uint64) {
+	item.Reserved -= quantity
+	item.Available += quantity
+}
+
+// Commit removes reserved quantity of shipped order from stock
+func (item *StockItem) Commit(quantity uint64) {
+	item.Reserved -= quantity
+}
+
+type StockItemRepositoryRead interface {
+	FindBySKU(sku SKU) (*StockItem, error)
+	FindAll() ([]StockItem, error)
+}
+
+type StockItemRepository interface {
+	StockItemRepositoryRead
+	// FindBySKUsForUpdate locks found items in sku order, missing items are skipped
+	FindBySKUsForUpdate(skus []SKU) ([]StockItem, error)
+	Store(item *StockItem) error
+}
+
+func validateSKU(sku SKU) error {
+	if sku == "" || len(sku) > maxSKULen {
+		return errors.WithStack(ErrInvalidSKU)
+	}
+	return nil
+}
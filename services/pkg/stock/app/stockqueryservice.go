@@ -0,0 +1,24 @@
+package app
+
+func NewStockQueryService(repoRead StockItemRepositoryRead) StockQueryService {
+	return &stockQueryService{
+		repoRead: repoRead,
+	}
+}
+
+type StockQueryService interface {
+	StockItem(sku SKU) (*StockItem, error)
+	StockItems() ([]StockItem, error)
+}
+
+type stockQueryService struct {
+	repoRead StockItemRepositoryRead
+}
+
+func (s *stockQueryService) StockItem(sku SKU) (*StockItem, error) {
+	return s.repoRead.FindBySKU(sku)
+}
+
+func (s *stockQueryService) StockItems() ([]StockItem, error) {
+	return s.repoRead.FindAll()
+}
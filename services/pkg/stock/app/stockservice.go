@@ -0,0 +1,221 @@
+package app
+
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/storedevent"
+
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewStockService(trUnitFactory TransactionalUnitFactory, eventSender storedevent.Sender) StockService {
+	return &stockService{
+		trUnitFactory: trUnitFactory,
+		eventSender:   eventSender,
+	}
+}
+
+type StockService interface {
+	SetAvailableQuantity(sku SKU, available uint64) error
+	ReserveOrder(orderID OrderID, items []ReservationItem) error
+	ReleaseOrder(orderID OrderID) error
+	CommitOrder(orderID OrderID) error
+}
+
+type stockService struct {
+	trUnitFactory TransactionalUnitFactory
+	eventSender   storedevent.Sender
+}
+
+// SetAvailableQuantity replaces quantity available for new orders, reserved quantity is kept
+func (s *stockService) SetAvailableQuantity(sku SKU, available uint64) error {
+	if err := validateSKU(sku); err != nil {
+		return err
+	}
+	return s.executeInTransaction(func(provider RepositoryProvider) error {
+		repo := provider.StockItemRepository()
+		items, err := repo.FindBySKUsForUpdate([]SKU{sku})
+		if err != nil {
+			return err
+		}
+		item := StockItem{SKU: sku}
+		if len(items) > 0 {
+			item = items[0]
+		}
+		item.Available = available
+		return repo.Store(&item)
+	})
+}
+
+// ReserveOrder reserves all order items or none of them and publishes the reservation result,
+// lack of stock is reported with reservation failed event instead of an error
+func (s *stockService) ReserveOrder(orderID OrderID, items []ReservationItem) error {
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		_, err := provider.ReservationRepository().FindByOrderIDForUpdate(orderID)
+		if err == nil {
+			// order is already reserved
+			return nil
+		}
+		if errors.Cause(err) != ErrReservationNotFound {
+			return err
+		}
+
+		event, err := reserveOrderItems(provider, orderID, items)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(provider, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.eventSender.SendStoredEvents()
+	return nil
+}
+
+// ReleaseOrder returns reserved items of rejected or cancelled order back to stock
+func (s *stockService) ReleaseOrder(orderID OrderID) error {
+	err := s.executeInTransaction(func(provider RepositoryProvider) error {
+		released, err := completeReservation(provider, orderID, ReservationStatusReleased)
+		if err != nil || !released {
+			return err
+		}
+		return s.addEvent(provider, NewStockReleasedEvent(orderID))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.eventSender.SendStoredEvents()
+	return nil
+}
+
+// CommitOrder removes reserved items of completed order from stock
+func (s *stockService) CommitOrder(orderID OrderID) error {
+	return s.executeInTransaction(func(provider RepositoryProvider) error {
+		_, err := completeReservation(provider, orderID, ReservationStatusCommitted)
+		return err
+	})
+}
+
+func (s *stockService) addEvent(provider RepositoryProvider, event integrationevent.EventData) error {
+	if err := provider.EventStore().Add(event); err != nil {
+		return err
+	}
+	s.eventSender.EventStored(event.UID)
+	return nil
+}
+
+func (s *stockService) executeInTransaction(f func(RepositoryProvider) error) (err error) {
+	var trUnit TransactionalUnit
+	trUnit, err = s.trUnitFactory.NewTransactionalUnit()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = trUnit.Complete(err)
+	}()
+	err = f(trUnit)
+	return err
+}
+
+// reserveOrderItems returns reservation result event, error is returned only if reservation should be retried
+func reserveOrderItems(provider RepositoryProvider, orderID OrderID, items []ReservationItem) (integrationevent.EventData, error) {
+	stockItems, err := lockStockItems(provider.StockItemRepository(), items)
+	if err != nil {
+		return integrationevent.EventData{}, err
+	}
+	for _, item := range items {
+		stockItem, ok := stockItems[item.SKU]
+		if !ok {
+			return NewStockReservationFailedEvent(orderID, ReservationFailureOutOfStock, item.SKU), nil
+		}
+		if err = stockItem.Reserve(item.Quantity); err != nil {
+			if errors.Cause(err) == ErrNotEnoughStock {
+				return NewStockReservationFailedEvent(orderID, ReservationFailureOutOfStock, item.SKU), nil
+			}
+			return integrationevent.EventData{}, err
+		}
+	}
+
+	if err = storeStockItems(provider.StockItemRepository(), stockItems); err != nil {
+		return integrationevent.EventData{}, err
+	}
+	err = provider.ReservationRepository().Store(&Reservation{
+		OrderID:      orderID,
+		Items:        items,
+		Status:       ReservationStatusReserved,
+		CreationDate: time.Now(),
+		UpdateDate:   time.Now(),
+	})
+	if err != nil {
+		return integrationevent.EventData{}, err
+	}
+	return NewStockReservedEvent(orderID), nil
+}
+
+// completeReservation moves active reservation to the final status, completed reports whether it was active
+func completeReservation(provider RepositoryProvider, orderID OrderID, status ReservationStatus) (completed bool, err error) {
+	reservationRepo := provider.ReservationRepository()
+	reservation, err := reservationRepo.FindByOrderIDForUpdate(orderID)
+	if err != nil {
+		if errors.Cause(err) == ErrReservationNotFound {
+			// reservation has failed, nothing to release
+			return false, nil
+		}
+		return false, err
+	}
+	if reservation.Status != ReservationStatusReserved {
+		return false, nil
+	}
+
+	stockItems, err := lockStockItems(provider.StockItemRepository(), reservation.Items)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range reservation.Items {
+		stockItem, ok := stockItems[item.SKU]
+		if !ok {
+			return false, errors.Wrapf(ErrStockItemNotFound, "sku '%s'", item.SKU)
+		}
+		if status == ReservationStatusReleased {
+			stockItem.Release(item.Quantity)
+		} else {
+			stockItem.Commit(item.Quantity)
+		}
+	}
+	if err = storeStockItems(provider.StockItemRepository(), stockItems); err != nil {
+		return false, err
+	}
+
+	reservation.Status = status
+	reservation.UpdateDate = time.Now()
+	return true, reservationRepo.Store(reservation)
+}
+
+func lockStockItems(repo StockItemRepository, items []ReservationItem) (map[SKU]*StockItem, error) {
+	skus := make([]SKU, 0, len(items))
+	for _, item := range items {
+		skus = append(skus, item.SKU)
+	}
+	stockItems, err := repo.FindBySKUsForUpdate(skus)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[SKU]*StockItem, len(stockItems))
+	for i := range stockItems {
+		res[stockItems[i].SKU] = &stockItems[i]
+	}
+	return res, nil
+}
+
+func storeStockItems(repo StockItemRepository, stockItems map[SKU]*StockItem) error {
+	for _, stockItem := range stockItems {
+		if err := repo.Store(stockItem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package app
+
+import "arch-homework/pkg/common/app/storedevent"
+
+type RepositoryProvider interface {
+	StockItemRepository() StockItemRepository
+	ReservationRepository() ReservationRepository
+	ProcessedEventRepository() ProcessedEventRepository
+	EventStore() storedevent.EventStore
+}
+
+type TransactionalUnit interface {
+	RepositoryProvider
+	TransactionalUnitFactory
+	Complete(err error) error
+}
+
+type TransactionalUnitFactory interface {
+	NewTransactionalUnit() (TransactionalUnit, error)
+}
@@ -0,0 +1,81 @@
+package integrationevent
+
+import (
+	"arch-homework/pkg/common/app/integrationevent"
+	"arch-homework/pkg/common/app/uuid"
+	"arch-homework/pkg/stock/app"
+
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+const typeOrderCreated = "order.order_created"
+const typeOrderRejected = "order.order_rejected"
+const typeOrderCancelled = "order.order_cancelled"
+const typeOrderCompleted = "order.order_completed"
+
+func NewEventParser() app.IntegrationEventParser {
+	return eventParser{}
+}
+
+type eventParser struct {
+}
+
+func (e eventParser) ParseIntegrationEvent(event integrationevent.EventData) (app.OrderEvent, error) {
+	switch event.Type {
+	case typeOrderCreated:
+		return parseOrderCreatedEvent(event.Body)
+	case typeOrderRejected:
+		return parseOrderStatusEvent(event.Body, app.NewOrderRejectedEvent)
+	case typeOrderCancelled:
+		return parseOrderStatusEvent(event.Body, app.NewOrderCancelledEvent)
+	case typeOrderCompleted:
+		return parseOrderStatusEvent(event.Body, app.NewOrderCompletedEvent)
+	default:
+		return nil, nil
+	}
+}
+
+func parseOrderCreatedEvent(strBody string) (app.OrderEvent, error) {
+	body, err := parseOrderEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]app.ReservationItem, 0, len(body.Items))
+	for _, item := range body.Items {
+		items = append(items, app.ReservationItem{
+			SKU:      app.SKU(item.SKU),
+			Quantity: item.Quantity,
+		})
+	}
+	return app.NewOrderCreatedEvent(app.OrderID(body.OrderID), items), nil
+}
+
+func parseOrderStatusEvent(strBody string, newEvent func(orderID app.OrderID) app.OrderEvent) (app.OrderEvent, error) {
+	body, err := parseOrderEvent(strBody)
+	if err != nil {
+		return nil, err
+	}
+	return newEvent(app.OrderID(body.OrderID)), nil
+}
+
+func parseOrderEvent(strBody string) (orderEventBody, error) {
+	var body orderEventBody
+	err := json.Unmarshal([]byte(strBody), &body)
+	if err != nil {
+		return body, errors.WithStack(err)
+	}
+	err = uuid.ValidateUUID(body.OrderID)
+	return body, errors.WithStack(err)
+}
+
+type orderEventBody struct {
+	OrderID string               `json:"order_id"`
+	Items   []orderItemEventBody `json:"items"`
+}
+
+type orderItemEventBody struct {
+	SKU      string `json:"sku"`
+	Quantity uint64 `json:"quantity"`
+}
@@ -1,23 +1,24 @@
 package postgres
 
 import (
+	"arch-homework/pkg/common/app/integrationevent"
 	"arch-homework/pkg/common/infrastructure/postgres"
-	"arch-homework/pkg/order/app"
+	"arch-homework/pkg/stock/app"
 
 	"database/sql"
 
 	"github.com/pkg/errors"
 )
 
-func NewProcessedRequestRepository(client postgres.Client) app.ProcessedRequestRepository {
-	return &processedRequestRepository{client: client}
+func NewProcessedEventRepository(client postgres.Client) app.ProcessedEventRepository {
+	return &processedEventRepository{client: client}
 }
 
-type processedRequestRepository struct {
+type processedEventRepository struct {
 	client postgres.Client
 }
 
-func (repo *processedRequestRepository) SetRequestProcessed(uid app.RequestID) (alreadyProcessed bool, err error) {
+func (repo *processedEventRepository) SetEventProcessed(uid integrationevent.EventUID) (alreadyProcessed bool, err error) {
 	const query = `INSERT INTO processed_request (uid) VALUES ($1) ON CONFLICT DO NOTHING RETURNING uid`
 
 	var resUID string
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"arch-homework/pkg/common/infrastructure/postgres"
+	"arch-homework/pkg/stock/app"
+
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func NewReservationRepository(client postgres.Client) app.ReservationRepository {
+	return &reservationRepository{client: client}
+}
+
+type reservationRepository struct {
+	client postgres.Client
+}
+
+func (repo *reservationRepository) Store(reservation *app.Reservation) error {
+	const query = `
+			INSERT INTO reservation (order_id, status, created_at, updated_at)
+			VALUES (:order_id, :status, :created_at, :updated_at)
+			ON CONFLICT (order_id) DO UPDATE SET
+				status = excluded.status,
+				updated_at = excluded.updated_at
+		`
+
+	reservationx := sqlxReservation{
+		OrderID:      string(reservation.OrderID),
+		Status:       int(reservation.Status),
+		CreationDate: reservation.CreationDate,
+		UpdateDate:   reservation.UpdateDate,
+	}
+
+	_, err := repo.client.NamedExec(query, &reservationx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return repo.storeItems(reservation)
+}
+
+func (repo *reservationRepository) FindByOrderIDForUpdate(orderID app.OrderID) (*app.Reservation, error) {
+	const query = `SELECT order_id, status, created_at, updated_at FROM reservation WHERE order_id = $1 FOR UPDATE`
+
+	var reservation sqlxReservation
+	err := repo.client.Get(&reservation, query, string(orderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, app.ErrReservationNotFound
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	const itemsQuery = `SELECT order_id, sku, quantity FROM reservation_item WHERE order_id = $1 ORDER BY sku`
+
+	var items []*sqlxReservationItem
+	err = repo.client.Select(&items, itemsQuery, string(orderID))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := app.Reservation{
+		OrderID:      app.OrderID(reservation.OrderID),
+		Items:        make([]app.ReservationItem, 0, len(items)),
+		Status:       app.ReservationStatus(reservation.Status),
+		CreationDate: reservation.CreationDate,
+		UpdateDate:   reservation.UpdateDate,
+	}
+	for _, item := range items {
+		res.Items = append(res.Items, app.ReservationItem{
+			SKU:      app.SKU(item.SKU),
+			Quantity: item.Quantity,
+		})
+	}
+	return &res, nil
+}
+
+func (repo *reservationRepository) storeItems(reservation *app.Reservation) error {
+	const query = `
+			INSERT INTO reservation_item (order_id, sku, quantity)
+			VALUES (:order_id, :sku, :quantity)
+			ON CONFLICT (order_id, sku) DO NOTHING
+		`
+
+	for _, item := range reservation.Items {
+		itemx := sqlxReservationItem{
+			OrderID:  string(reservation.OrderID),
+			SKU:      string(item.SKU),
+			Quantity: item.Quantity,
+		}
+		_, err := repo.client.NamedExec(query, &itemx)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+type sqlxReservation struct {
+	OrderID      string    `db:"order_id"`
+	Status       int       `db:"status"`
+	CreationDate time.Time `db:"created_at"`
+	UpdateDate   time.Time `db:"updated_at"`
+}
+
+type sqlxReservationItem struct {
+	OrderID  string `db:"order_id"`
+	SKU      string `db:"sku"`
+	Quantity uint64 `db:"quantity"`
+}
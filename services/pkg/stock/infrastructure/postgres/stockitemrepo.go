@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"arch-homework/pkg/common/infrastructure/postgres"
+	"arch-homework/pkg/stock/app"
+
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+const selectStockItemQuery = `SELECT sku, available, reserved FROM stock_item`
+
+func NewStockItemRepository(client postgres.Client) app.StockItemRepository {
+	return &stockItemRepository{client: client}
+}
+
+type stockItemRepository struct {
+	client postgres.Client
+}
+
+func (repo *stockItemRepository) Store(item *app.StockItem) error {
+	const query = `
+			INSERT INTO stock_item (sku, available, reserved)
+			VALUES (:sku, :available, :reserved)
+			ON CONFLICT (sku) DO UPDATE SET
+				available = excluded.available,
+				reserved = excluded.reserved
+		`
+
+	itemx := sqlxStockItem{
+		SKU:       string(item.SKU),
+		Available: item.Available,
+		Reserved:  item.Reserved,
+	}
+
+	_, err := repo.client.NamedExec(query, &itemx)
+	return errors.WithStack(err)
+}
+
+func (repo *stockItemRepository) FindBySKU(sku app.SKU) (*app.StockItem, error) {
+	const query = selectStockItemQuery + ` WHERE sku = $1`
+
+	var item sqlxStockItem
+	err := repo.client.Get(&item, query, string(sku))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, app.ErrStockItemNotFound
+		}
+		return nil, errors.WithStack(err)
+	}
+	res := sqlxStockItemToStockItem(&item)
+	return &res, nil
+}
+
+func (repo *stockItemRepository) FindAll() ([]app.StockItem, error) {
+	return repo.findList(selectStockItemQuery + ` ORDER BY sku`)
+}
+
+func (repo *stockItemRepository) FindBySKUsForUpdate(skus []app.SKU) ([]app.StockItem, error) {
+	// rows are locked in the same order by all transactions to avoid deadlocks
+	const sqlQuery = selectStockItemQuery + ` WHERE sku IN (?) ORDER BY sku FOR UPDATE`
+
+	strSKUs := make([]string, 0, len(skus))
+	for _, sku := range skus {
+		strSKUs = append(strSKUs, string(sku))
+	}
+	query, params, err := sqlx.In(sqlQuery, strSKUs)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return repo.findList(sqlx.Rebind(sqlx.DOLLAR, query), params...)
+}
+
+func (repo *stockItemRepository) findList(query string, params ...interface{}) ([]app.StockItem, error) {
+	var items []*sqlxStockItem
+	err := repo.client.Select(&items, query, params...)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	res := make([]app.StockItem, 0, len(items))
+	for _, item := range items {
+		res = append(res, sqlxStockItemToStockItem(item))
+	}
+	return res, nil
+}
+
+func sqlxStockItemToStockItem(item *sqlxStockItem) app.StockItem {
+	return app.StockItem{
+		SKU:       app.SKU(item.SKU),
+		Available: item.Available,
+		Reserved:  item.Reserved,
+	}
+}
+
+type sqlxStockItem struct {
+	SKU       string `db:"sku"`
+	Available uint64 `db:"available"`
+	Reserved  uint64 `db:"reserved"`
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"arch-homework/pkg/common/app/storedevent"
+	"arch-homework/pkg/common/infrastructure/postgres"
+	"arch-homework/pkg/stock/app"
+
+	"github.com/pkg/errors"
+)
+
+func NewTransactionalUnitFactory(client postgres.TransactionalClient) app.TransactionalUnitFactory {
+	return &transactionalUnitFactory{client: client}
+}
+
+type transactionalUnitFactory struct {
+	client postgres.TransactionalClient
+}
+
+func (d *transactionalUnitFactory) NewTransactionalUnit() (app.TransactionalUnit, error) {
+	transaction, err := d.client.BeginTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return &transactionalUnit{transaction: transaction, nestedLevel: 1}, nil
+}
+
+type transactionalUnit struct {
+	transaction postgres.Transaction
+	nestedLevel uint
+	completeErr error
+}
+
+func (t *transactionalUnit) NewTransactionalUnit() (app.TransactionalUnit, error) {
+	t.nestedLevel++
+	return t, nil
+}
+
+func (t *transactionalUnit) StockItemRepository() app.StockItemRepository {
+	return NewStockItemRepository(t.transaction)
+}
+
+func (t *transactionalUnit) ReservationRepository() app.ReservationRepository {
+	return NewReservationRepository(t.transaction)
+}
+
+func (t *transactionalUnit) ProcessedEventRepository() app.ProcessedEventRepository {
+	return NewProcessedEventRepository(t.transaction)
+}
+
+func (t *transactionalUnit) EventStore() storedevent.EventStore {
+	return NewEventStore(t.transaction)
+}
+
+func (t *transactionalUnit) Complete(err error) error {
+	t.nestedLevel--
+
+	if t.completeErr != nil {
+		return t.completeErr
+	}
+
+	if err != nil {
+		rollbackErr := t.transaction.Rollback()
+		if rollbackErr != nil {
+			err = errors.Wrap(err, rollbackErr.Error())
+		}
+		t.completeErr = err
+		return err
+	}
+	if t.nestedLevel > 0 {
+		return nil
+	}
+	return errors.WithStack(t.transaction.Commit())
+}
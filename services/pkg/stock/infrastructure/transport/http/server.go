@@ -0,0 +1,203 @@
+package http
+
+import (
+	"arch-homework/pkg/common/infrastructure/metrics"
+	"arch-homework/pkg/stock/app"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const PathPrefixInternal = "/internal/api/v1/"
+
+const (
+	stockItemsEndpoint = PathPrefixInternal + "stock"
+	stockItemEndpoint  = PathPrefixInternal + "stock/{sku}"
+)
+
+const (
+	errorCodeUnknown           = 0
+	errorCodeInvalidSKU        = 1
+	errorCodeStockItemNotFound = 2
+)
+
+func NewEndpointLabelCollector() metrics.EndpointLabelCollector {
+	return endpointLabelCollector{}
+}
+
+type endpointLabelCollector struct {
+}
+
+func (e endpointLabelCollector) EndpointLabelForURI(uri string) string {
+	if strings.HasPrefix(uri, PathPrefixInternal) {
+		r, _ := regexp.Compile("^" + PathPrefixInternal + "stock/[^/]+$")
+		if r.MatchString(uri) {
+			return stockItemEndpoint
+		}
+	}
+	return uri
+}
+
+func NewServer(stockService app.StockService, stockQueryService app.StockQueryService, logger *logrus.Logger) *Server {
+	return &Server{
+		stockService:      stockService,
+		stockQueryService: stockQueryService,
+		logger:            logger,
+	}
+}
+
+type Server struct {
+	stockService      app.StockService
+	stockQueryService app.StockQueryService
+	logger            *logrus.Logger
+}
+
+func (s *Server) MakeInternalHandler() http.Handler {
+	router := mux.NewRouter()
+	router.Methods(http.MethodGet).Path(stockItemsEndpoint).Handler(s.makeHandlerFunc(s.getStockItemsEndpoint))
+	router.Methods(http.MethodGet).Path(stockItemEndpoint).Handler(s.makeHandlerFunc(s.getStockItemEndpoint))
+	router.Methods(http.MethodPut).Path(stockItemEndpoint).Handler(s.makeHandlerFunc(s.setStockItemEndpoint))
+	return router
+}
+
+func (s *Server) makeHandlerFunc(fn func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		fields := logrus.Fields{
+			"method": r.Method,
+			"host":   r.Host,
+			"path":   r.URL.Path,
+		}
+		if r.URL.RawQuery != "" {
+			fields["query"] = r.URL.RawQuery
+		}
+		if r.PostForm != nil {
+			fields["post"] = r.PostForm
+		}
+
+		if r.Body != nil {
+			bytesBody, _ := ioutil.ReadAll(r.Body)
+			_ = r.Body.Close()
+			if len(bytesBody) > 0 {
+				r.Body = ioutil.NopCloser(bytes.NewBuffer(bytesBody))
+				fields["body"] = string(bytesBody)
+			}
+		}
+		headersBytes, _ := json.Marshal(r.Header)
+		fields["headers"] = string(headersBytes)
+
+		err := fn(w, r)
+
+		if err != nil {
+			writeErrorResponse(w, err)
+
+			fields["err"] = err
+			s.logger.WithFields(fields).Error()
+		} else {
+			s.logger.WithFields(fields).Info("call")
+		}
+	}
+}
+
+func (s *Server) getStockItemsEndpoint(w http.ResponseWriter, _ *http.Request) error {
+	items, err := s.stockQueryService.StockItems()
+	if err != nil {
+		return err
+	}
+	infos := make([]stockItemInfo, 0, len(items))
+	for i := range items {
+		infos = append(infos, toStockItemInfo(&items[i]))
+	}
+	writeResponse(w, infos)
+	return nil
+}
+
+func (s *Server) getStockItemEndpoint(w http.ResponseWriter, r *http.Request) error {
+	item, err := s.stockQueryService.StockItem(getSKUFromRequest(r))
+	if err != nil {
+		return err
+	}
+	writeResponse(w, toStockItemInfo(item))
+	return nil
+}
+
+func (s *Server) setStockItemEndpoint(w http.ResponseWriter, r *http.Request) error {
+	var info setStockItemInfo
+	bytesBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	_ = r.Body.Close()
+	if err = json.Unmarshal(bytesBody, &info); err != nil {
+		return err
+	}
+
+	if err = s.stockService.SetAvailableQuantity(getSKUFromRequest(r), info.Available); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func getSKUFromRequest(r *http.Request) app.SKU {
+	return app.SKU(mux.Vars(r)["sku"])
+}
+
+func toStockItemInfo(item *app.StockItem) stockItemInfo {
+	return stockItemInfo{
+		SKU:       string(item.SKU),
+		Available: item.Available,
+		Reserved:  item.Reserved,
+	}
+}
+
+func writeResponse(w http.ResponseWriter, response interface{}) {
+	js, err := json.Marshal(response)
+	if err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(js)
+}
+
+func writeErrorResponse(w http.ResponseWriter, err error) {
+	info := errorInfo{Code: errorCodeUnknown, Message: err.Error()}
+	switch errors.Cause(err) {
+	case app.ErrInvalidSKU:
+		info.Code = errorCodeInvalidSKU
+		w.WriteHeader(http.StatusBadRequest)
+	case app.ErrStockItemNotFound:
+		info.Code = errorCodeStockItemNotFound
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	js, _ := json.Marshal(info)
+	_, _ = w.Write(js)
+}
+
+type errorInfo struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type stockItemInfo struct {
+	SKU       string `json:"sku"`
+	Available uint64 `json:"available"`
+	Reserved  uint64 `json:"reserved"`
+}
+
+type setStockItemInfo struct {
+	Available uint64 `json:"available"`
+}
@@ -2,7 +2,6 @@ package app
 
 type RepositoryProvider interface {
 	UserRepository() UserRepository
-	ProcessedRequestRepository() ProcessedRequestRepository
 }
 
 type ReadRepositoryProvider interface {
@@ -4,8 +4,6 @@ import (
 	"github.com/pkg/errors"
 )
 
-var ErrAlreadyProcessed = errors.New("request with this id already processed")
-
 func NewUserService(dbDependency DBDependency) *UserService {
 	return &UserService{dbDependency: dbDependency}
 }
@@ -14,17 +12,9 @@ type UserService struct {
 	dbDependency DBDependency
 }
 
-func (s *UserService) Update(requestID RequestID, id UserID, firstName, lastName *string, email *Email, phone *Phone) error {
+// Update stores changed user fields, retried requests are replayed by the idempotency middleware and do not reach the service
+func (s *UserService) Update(id UserID, firstName, lastName *string, email *Email, phone *Phone) error {
 	return s.executeInTransaction(func(provider RepositoryProvider) error {
-		eventRepo := provider.ProcessedRequestRepository()
-		alreadyProcessed, err := eventRepo.SetRequestProcessed(requestID)
-		if err != nil {
-			return err
-		}
-		if alreadyProcessed {
-			return ErrAlreadyProcessed
-		}
-
 		userRepo := provider.UserRepository()
 		user, err := s.dbDependency.UserRepositoryRead().FindByID(id)
 		if err != nil {
@@ -35,10 +35,6 @@ func (t *transactionalUnit) UserRepository() app.UserRepository {
 	return NewUserRepository(t.transaction)
 }
 
-func (t *transactionalUnit) ProcessedRequestRepository() app.ProcessedRequestRepository {
-	return NewProcessedRequestRepository(t.transaction)
-}
-
 func (t *transactionalUnit) Complete(err error) error {
 	if err != nil {
 		rollbackErr := t.transaction.Rollback()
@@ -164,8 +164,7 @@ func (s *Server) updateUserHandler(w http.ResponseWriter, r *http.Request) error
 		return errForbidden
 	}
 
-	requestID, err := s.getRequestIDHeader(r)
-	if err != nil {
+	if err = checkRequestIDHeader(r); err != nil {
 		return err
 	}
 
@@ -190,7 +189,7 @@ func (s *Server) updateUserHandler(w http.ResponseWriter, r *http.Request) error
 		phone = &phoneValue
 	}
 
-	err = s.userService.Update(requestID, id, info.FirstName, info.LastName, email, phone)
+	err = s.userService.Update(id, info.FirstName, info.LastName, email, phone)
 	if err != nil {
 		return err
 	}
@@ -214,13 +213,13 @@ func (s *Server) extractAuthorizationData(r *http.Request) (jwtauth.TokenData, e
 	return tokenData, nil
 }
 
-func (s *Server) getRequestIDHeader(r *http.Request) (app.RequestID, error) {
-	requestID := r.Header.Get(requestIDHeader)
-	err := uuid.ValidateUUID(requestID)
+// checkRequestIDHeader requires the request id, so retries of the request are always replayed by the idempotency middleware
+func checkRequestIDHeader(r *http.Request) error {
+	err := uuid.ValidateUUID(r.Header.Get(requestIDHeader))
 	if err != nil {
-		return "", errors.Wrap(errInvalidRequestID, err.Error())
+		return errors.Wrap(errInvalidRequestID, err.Error())
 	}
-	return app.RequestID(requestID), nil
+	return nil
 }
 
 func getIDFromRequest(r *http.Request) (app.UserID, error) {
@@ -252,9 +251,6 @@ func writeErrorResponse(w http.ResponseWriter, err error) {
 	case errInvalidRequestID:
 		info.Code = errorCodeInvalidRequestID
 		w.WriteHeader(http.StatusBadRequest)
-	case app.ErrAlreadyProcessed:
-		info.Code = errorCodeAlreadyProcessed
-		w.WriteHeader(http.StatusConflict)
 	case app.ErrUserNotFound:
 		info.Code = errorCodeUserNotFound
 		w.WriteHeader(http.StatusNotFound)